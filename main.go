@@ -3,13 +3,18 @@ package main
 import (
 	"fmt"
 	"html/template"
-	"log"
+	"io"
+	"log/slog"
 	"mime/multipart"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/MhunterDev/img2ascii/source/banners"
 	"github.com/MhunterDev/img2ascii/source/handlers"
+	"github.com/MhunterDev/img2ascii/source/img2ascii"
 	"github.com/MhunterDev/img2ascii/source/middleware"
 	"github.com/MhunterDev/img2ascii/source/www"
 	"github.com/gin-gonic/gin"
@@ -19,8 +24,15 @@ var (
 	outputDir     = getEnv("IMG2ASCII_OUTPUT_DIR", "/tmp/img2ascii")
 	outputFile    = getEnv("IMG2ASCII_OUTPUT_FILE", "/tmp/img2ascii/output.txt")
 	wwwDir        = getEnv("IMG2ASCII_WWW_DIR", "/tmp/img2ascii/www")
+	templateDir   = getEnv("IMG2ASCII_TEMPLATE_DIR", "")
+	fontDir       = getEnv("IMG2ASCII_FONT_DIR", "")
+	fallbackFonts = getEnv("IMG2ASCII_FALLBACK_FONTS", "SourceCodePro-Regular")
 	maxUploadSize = int64(2 << 20)
 	maxBannerLen  = 64
+	maxPixels     = getEnv("IMG2ASCII_MAX_IMAGE_PIXELS", "")
+	imageLimits   = img2ascii.DefaultLimits
+	logFormat     = getEnv("IMG2ASCII_LOG_FORMAT", "text")
+	logLevel      = getEnv("IMG2ASCII_LOG_LEVEL", "info")
 )
 
 func getEnv(key, fallback string) string {
@@ -30,6 +42,39 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// newLogger builds a slog.Logger writing to w in the given format ("text" or
+// "json") at the given level ("debug", "info", "warn" or "error")
+func newLogger(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level: %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch strings.ToLower(format) {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format: %q", format)
+	}
+}
+
+// parseImageLimits returns the default image limits with MaxPixels set from
+// s, a pixel count; an empty s keeps the default
+func parseImageLimits(s string) (img2ascii.Limits, error) {
+	limits := img2ascii.DefaultLimits
+	if s == "" {
+		return limits, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return img2ascii.Limits{}, fmt.Errorf("invalid max image pixels: %q", s)
+	}
+	limits.MaxPixels = n
+	return limits, nil
+}
+
 func checkAndPopulate() error {
 	// Validate configuration
 	if maxUploadSize <= 0 {
@@ -38,23 +83,28 @@ func checkAndPopulate() error {
 	if maxBannerLen <= 0 {
 		return fmt.Errorf("invalid max banner length: %d", maxBannerLen)
 	}
+	limits, err := parseImageLimits(maxPixels)
+	if err != nil {
+		return err
+	}
+	imageLimits = limits
 
 	if _, err := os.Stat(outputDir); os.IsNotExist(err) {
 		if err := os.MkdirAll(outputDir, 0700); err != nil {
-			log.Printf("Failed to create outputDir: %v", err)
+			slog.Error("failed to create output dir", "path", outputDir, "err", err)
 			return err
 		}
 	}
 	if _, err := os.Stat(wwwDir); os.IsNotExist(err) {
 		if err := os.Mkdir(wwwDir, 0700); err != nil {
-			log.Printf("Failed to create wwwDir: %v", err)
+			slog.Error("failed to create www dir", "path", wwwDir, "err", err)
 			return err
 		}
 	}
 	if _, err := os.Stat(outputFile); os.IsNotExist(err) {
 		f, err := os.Create(outputFile)
 		if err != nil {
-			log.Printf("Failed to create outputFile: %v", err)
+			slog.Error("failed to create output file", "path", outputFile, "err", err)
 			return err
 		}
 		f.Close()
@@ -81,14 +131,52 @@ func getStaticFS() (tmpl *template.Template, staticFS http.FileSystem, err error
 	return tmpl, http.FS(www.StaticFiles), nil
 }
 
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
+
 func main() {
+	logger, err := newLogger(os.Stderr, logFormat, logLevel)
+	if err != nil {
+		fatal("logger configuration error", err)
+	}
+	slog.SetDefault(logger)
+
 	if err := checkAndPopulate(); err != nil {
-		log.Fatalf("Startup error: %v", err)
+		fatal("startup error", err)
+	}
+
+	if templateDir != "" {
+		names, err := img2ascii.LoadTemplateFormats(templateDir, img2ascii.DefaultTemplateLimits)
+		if err != nil {
+			fatal("template format error", err)
+		}
+		slog.Info("loaded template formats", "dir", templateDir, "formats", names)
+	}
+
+	fonts := banners.DefaultFonts()
+	if fontDir != "" {
+		added, err := fonts.LoadDir(fontDir)
+		if err != nil {
+			fatal("font loading error", err)
+		}
+		slog.Info("loaded fonts", "dir", fontDir, "fonts", added)
+	}
+	var fallback []banners.Font
+	for _, name := range strings.Split(fallbackFonts, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if _, err := fonts.Lookup(banners.Font(name)); err != nil {
+			fatal("font loading error", err)
+		}
+		fallback = append(fallback, banners.Font(name))
 	}
 
 	tmpl, staticFS, err := getStaticFS()
 	if err != nil {
-		log.Fatalf("Static/template error: %v", err)
+		fatal("static/template error", err)
 	}
 	globalTmpl = tmpl
 
@@ -96,10 +184,17 @@ func main() {
 		OutputDir:     outputDir,
 		MaxUploadSize: maxUploadSize,
 		MaxBannerLen:  maxBannerLen,
+		ImageLimits:   imageLimits,
 		GlobalTmpl:    globalTmpl,
+		Logger:        logger,
+		Fonts:         fonts,
+		FallbackFonts: fallback,
 	}
 
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(middleware.RequestID())
+	r.Use(middleware.RequestLogger(logger))
 
 	// Add rate limiting middleware
 	// Allow 10 requests per minute per IP
@@ -110,8 +205,11 @@ func main() {
 	r.GET("/", handlers.HandleHome(cfg))
 	r.POST("/upload", handlers.HandleUpload(cfg))
 	r.POST("/banner", handlers.HandleBanner(cfg))
+	r.GET("/fonts", handlers.HandleFonts(cfg))
+	r.GET("/fonts/:name/flf", handlers.HandleFontFIGlet(cfg))
+	r.POST("/ansi", handlers.HandleANSI(cfg))
 
 	if err := r.Run(":8080"); err != nil {
-		log.Fatalf("Server error: %v", err)
+		fatal("server error", err)
 	}
 }
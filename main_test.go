@@ -1,11 +1,13 @@
 package main
 
 import (
+	"bytes"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/MhunterDev/img2ascii/source/handlers"
+	"github.com/MhunterDev/img2ascii/source/img2ascii"
 	"github.com/gin-gonic/gin"
 )
 
@@ -23,3 +25,63 @@ func TestHandleHome(t *testing.T) {
 		t.Errorf("Expected 200 or 500, got %d", w.Code)
 	}
 }
+
+func TestNewLogger(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		level    string
+		hasError bool
+		prefix   string
+	}{
+		{"Text info", "text", "info", false, "time="},
+		{"JSON debug", "json", "debug", false, "{"},
+		{"JSON uppercase", "JSON", "WARN", false, ""},
+		{"Invalid format", "xml", "info", true, ""},
+		{"Invalid level", "text", "verbose", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := newLogger(&buf, tt.format, tt.level)
+			if tt.hasError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			logger.Info("hello")
+			if !bytes.HasPrefix(buf.Bytes(), []byte(tt.prefix)) {
+				t.Errorf("Log output %q should start with %q", buf.String(), tt.prefix)
+			}
+		})
+	}
+}
+
+func TestParseImageLimits(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int64
+		hasError bool
+	}{
+		{"", img2ascii.DefaultLimits.MaxPixels, false},
+		{"1000000", 1000000, false},
+		{"0", 0, true},
+		{"-5", 0, true},
+		{"many", 0, true},
+	}
+	for _, tt := range tests {
+		limits, err := parseImageLimits(tt.value)
+		if (err != nil) != tt.hasError {
+			t.Errorf("parseImageLimits(%q) error = %v, hasError %v", tt.value, err, tt.hasError)
+			continue
+		}
+		if err == nil && limits.MaxPixels != tt.expected {
+			t.Errorf("parseImageLimits(%q) MaxPixels = %d, want %d", tt.value, limits.MaxPixels, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Package ansi implements the pieces of the DOS ANSI art format shared by the
+// exporter and importer: the CP437 character set, the 16-colour VGA palette
+// and SAUCE metadata records.
+package ansi
+
+import (
+	"image/color"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Columns is the width of the DOS text screen that ANSI art is drawn for
+const Columns = 80
+
+// Palette is the standard VGA text-mode palette in SGR order: index i is
+// selected by SGR 30+i (foreground) or 40+i (background), and 8-15 are the
+// bright variants enabled by SGR 1 (bold) or 5 (blink, with iCE colours).
+var Palette = [16]color.RGBA{
+	{0x00, 0x00, 0x00, 0xff}, // black
+	{0xaa, 0x00, 0x00, 0xff}, // red
+	{0x00, 0xaa, 0x00, 0xff}, // green
+	{0xaa, 0x55, 0x00, 0xff}, // brown
+	{0x00, 0x00, 0xaa, 0xff}, // blue
+	{0xaa, 0x00, 0xaa, 0xff}, // magenta
+	{0x00, 0xaa, 0xaa, 0xff}, // cyan
+	{0xaa, 0xaa, 0xaa, 0xff}, // light grey
+	{0x55, 0x55, 0x55, 0xff}, // dark grey
+	{0xff, 0x55, 0x55, 0xff}, // bright red
+	{0x55, 0xff, 0x55, 0xff}, // bright green
+	{0xff, 0xff, 0x55, 0xff}, // yellow
+	{0x55, 0x55, 0xff, 0xff}, // bright blue
+	{0xff, 0x55, 0xff, 0xff}, // bright magenta
+	{0x55, 0xff, 0xff, 0xff}, // bright cyan
+	{0xff, 0xff, 0xff, 0xff}, // white
+}
+
+// CP437 shade and block characters
+const (
+	LightShade  byte = 0xb0 // ░
+	MediumShade byte = 0xb1 // ▒
+	DarkShade   byte = 0xb2 // ▓
+	FullBlock   byte = 0xdb // █
+	LowerHalf   byte = 0xdc // ▄
+	UpperHalf   byte = 0xdf // ▀
+)
+
+// EncodeCP437 converts s to code page 437, replacing characters that have no
+// CP437 equivalent with '?'
+func EncodeCP437(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		b, ok := charmap.CodePage437.EncodeRune(r)
+		if !ok {
+			b = '?'
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// DecodeCP437 converts code page 437 bytes to a UTF-8 string
+func DecodeCP437(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = charmap.CodePage437.DecodeByte(c)
+	}
+	return string(runes)
+}
+
+// cp437Glyphs are the pictures DOS shows for the control codes 0x00-0x1f
+// when they are written to the screen; NUL is drawn as a blank
+var cp437Glyphs = [32]rune{
+	' ', '☺', '☻', '♥', '♦', '♣', '♠', '•', '◘', '○', '◙', '♂', '♀', '♪', '♫', '☼',
+	'►', '◄', '↕', '‼', '¶', '§', '▬', '↨', '↑', '↓', '→', '←', '∟', '↔', '▲', '▼',
+}
+
+// cp437Rune returns the character shown for a CP437 byte on screen
+func cp437Rune(b byte) rune {
+	switch {
+	case b < 0x20:
+		return cp437Glyphs[b]
+	case b == 0x7f:
+		return '⌂'
+	default:
+		return charmap.CodePage437.DecodeByte(b)
+	}
+}
@@ -0,0 +1,17 @@
+package ansi
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeCP437(t *testing.T) {
+	got := EncodeCP437("A░▒▓█é€")
+	want := []byte{'A', LightShade, MediumShade, DarkShade, FullBlock, 0x82, '?'}
+	if !bytes.Equal(got, want) {
+		t.Errorf("EncodeCP437() = % x, want % x", got, want)
+	}
+	if s := DecodeCP437(want[:6]); s != "A░▒▓█é" {
+		t.Errorf("DecodeCP437() = %q", s)
+	}
+}
@@ -0,0 +1,139 @@
+package ansi
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// CellHeight is the height in pixels of a character cell in rendered images,
+// as on a VGA text screen
+const CellHeight = 16
+
+// CellWidth returns the width in pixels of a character cell: 9 when the
+// SAUCE record asks for VGA letter spacing, otherwise 8
+func (s *Screen) CellWidth() int {
+	if s.Sauce != nil && s.Sauce.Flags&letterSpacingMask == FlagLetterSpacing9 {
+		return 9
+	}
+	return 8
+}
+
+// Bounds returns the size of the image drawn by Image
+func (s *Screen) Bounds() image.Rectangle {
+	return image.Rect(0, 0, s.Width*s.CellWidth(), s.Height*CellHeight)
+}
+
+// Image draws the screen in the VGA palette. Shade and block characters are
+// drawn as pixel patterns; other characters use face, which may be nil to
+// draw colours and blocks only.
+func (s *Screen) Image(face font.Face) *image.RGBA {
+	cw := s.CellWidth()
+	img := image.NewRGBA(s.Bounds())
+	var baseline int
+	if face != nil {
+		m := face.Metrics()
+		ascent, descent := m.Ascent.Ceil(), m.Descent.Ceil()
+		baseline = (CellHeight-ascent-descent)/2 + ascent
+	}
+	for y := 0; y < s.Height; y++ {
+		for x := 0; x < s.Width; x++ {
+			cell := s.At(x, y)
+			rect := image.Rect(x*cw, y*CellHeight, (x+1)*cw, (y+1)*CellHeight)
+			fg, bg := Palette[cell.Fg&0xf], Palette[cell.Bg&0xf]
+			draw.Draw(img, rect, image.NewUniform(bg), image.Point{}, draw.Src)
+			if drawBlock(img, rect, cell.Rune, fg) || face == nil || cell.Rune == ' ' {
+				continue
+			}
+			d := font.Drawer{
+				Dst:  img,
+				Src:  image.NewUniform(fg),
+				Face: face,
+				Dot:  fixed.P(rect.Min.X, rect.Min.Y+baseline),
+			}
+			// Centre the glyph in the cell
+			if adv, ok := face.GlyphAdvance(cell.Rune); ok {
+				d.Dot.X += (fixed.I(cw) - adv) / 2
+			}
+			d.DrawString(string(cell.Rune))
+		}
+	}
+	return img
+}
+
+// drawBlock draws the block elements used by ANSI art as exact pixel
+// patterns, reporting whether r was one of them
+func drawBlock(img *image.RGBA, rect image.Rectangle, r rune, fg color.RGBA) bool {
+	var lit func(x, y int) bool
+	w, h := rect.Dx(), rect.Dy()
+	switch r {
+	case '█':
+		lit = func(x, y int) bool { return true }
+	case '▀':
+		lit = func(x, y int) bool { return y < h/2 }
+	case '▄':
+		lit = func(x, y int) bool { return y >= h/2 }
+	case '▌':
+		lit = func(x, y int) bool { return x < w/2 }
+	case '▐':
+		lit = func(x, y int) bool { return x >= w/2 }
+	case '░':
+		lit = func(x, y int) bool { return y%2 == 0 && x%4 == 0 || y%2 == 1 && x%4 == 2 }
+	case '▒':
+		lit = func(x, y int) bool { return (x+y)%2 == 0 }
+	case '▓':
+		lit = func(x, y int) bool { return !(y%2 == 0 && x%4 == 2 || y%2 == 1 && x%4 == 0) }
+	default:
+		return false
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if lit(x, y) {
+				img.SetRGBA(rect.Min.X+x, rect.Min.Y+y, fg)
+			}
+		}
+	}
+	return true
+}
+
+// WriteHTML writes the screen as a standalone HTML page. Every character is
+// escaped and colours come only from the palette, so untrusted files cannot
+// inject markup.
+func (s *Screen) WriteHTML(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	title := "ANSI art"
+	if s.Sauce != nil && s.Sauce.Title != "" {
+		title = s.Sauce.Title
+	}
+	fmt.Fprintf(bw, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n", html.EscapeString(title))
+	bw.WriteString("<style>pre.ansi{display:inline-block;margin:0;background:#000;color:#aaa;font-family:monospace;line-height:1}</style>\n")
+	bw.WriteString("</head>\n<body>\n<pre class=\"ansi\">")
+	for y := 0; y < s.Height; y++ {
+		for x := 0; x < s.Width; {
+			cell := s.At(x, y)
+			end := x + 1
+			for end < s.Width && s.At(end, y).Fg == cell.Fg && s.At(end, y).Bg == cell.Bg {
+				end++
+			}
+			fmt.Fprintf(bw, "<span style=\"color:%s;background:%s\">", hexColor(Palette[cell.Fg&0xf]), hexColor(Palette[cell.Bg&0xf]))
+			for ; x < end; x++ {
+				bw.WriteString(html.EscapeString(string(s.At(x, y).Rune)))
+			}
+			bw.WriteString("</span>")
+		}
+		bw.WriteByte('\n')
+	}
+	bw.WriteString("</pre>\n</body>\n</html>\n")
+	return bw.Flush()
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
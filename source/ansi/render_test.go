@@ -0,0 +1,82 @@
+package ansi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/basicfont"
+)
+
+func TestScreenImage(t *testing.T) {
+	s, err := Parse([]byte("\x1b[1;33;44m\xdf\x1b[0mA"), ParseOptions{Width: 2})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	img := s.Image(basicfont.Face7x13)
+	if b := img.Bounds(); b.Dx() != 16 || b.Dy() != CellHeight {
+		t.Fatalf("Image is %dx%d, want 16x%d", b.Dx(), b.Dy(), CellHeight)
+	}
+	// Upper half block: yellow on top, blue below
+	if got := img.RGBAAt(3, 2); got != Palette[11] {
+		t.Errorf("Top half = %v, want yellow", got)
+	}
+	if got := img.RGBAAt(3, 12); got != Palette[4] {
+		t.Errorf("Bottom half = %v, want blue", got)
+	}
+	// The font draws some light grey pixels for "A"
+	lit := 0
+	for y := 0; y < CellHeight; y++ {
+		for x := 8; x < 16; x++ {
+			if img.RGBAAt(x, y) == Palette[7] {
+				lit++
+			}
+		}
+	}
+	if lit == 0 {
+		t.Error("Glyph A was not drawn")
+	}
+
+	// Without a face only colours and blocks are drawn
+	img = s.Image(nil)
+	if got := img.RGBAAt(3, 2); got != Palette[11] {
+		t.Errorf("Top half without face = %v, want yellow", got)
+	}
+}
+
+func TestScreenCellWidth(t *testing.T) {
+	record, _ := Sauce{DataType: DataTypeCharacter, TInfo1: 1, Flags: FlagLetterSpacing9}.MarshalBinary()
+	s, err := Parse(append([]byte("x"), record...), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if s.CellWidth() != 9 || s.Image(nil).Bounds().Dx() != 9 {
+		t.Errorf("CellWidth() = %d, want 9 with VGA letter spacing", s.CellWidth())
+	}
+}
+
+func TestScreenWriteHTML(t *testing.T) {
+	record, _ := Sauce{Title: "<script>"}.MarshalBinary()
+	data := append([]byte("\x1b[31m<b>&\x1b[0m ok"), record...)
+	s, err := Parse(data, ParseOptions{Width: 8})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := s.WriteHTML(&buf); err != nil {
+		t.Fatalf("WriteHTML() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"<title>&lt;script&gt;</title>",
+		`<span style="color:#aa0000;background:#000000">&lt;b&gt;&amp;</span>`,
+		`<span style="color:#aaaaaa;background:#000000"> ok </span>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "<b>") || strings.Contains(out, "<script>") {
+		t.Errorf("HTML contains unescaped markup:\n%s", out)
+	}
+}
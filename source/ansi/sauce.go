@@ -0,0 +1,155 @@
+package ansi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// SAUCE record layout (Standard Architecture for Universal Comment
+// Extensions, version 00)
+const (
+	sauceSize      = 128
+	commentLineLen = 64
+	maxComments    = 255
+	eofMarker      = 0x1a
+	sauceDateForm  = "20060102"
+)
+
+// Data and file types used for ANSI art
+const (
+	DataTypeCharacter = 1
+	FileTypeASCII     = 0
+	FileTypeANSI      = 1
+)
+
+// TFlags bits for character data
+const (
+	FlagNonBlink       = 1 << 0 // iCE colours: SGR 5 selects bright backgrounds
+	FlagLetterSpacing8 = 1 << 1 // render the font 8 pixels wide
+	FlagLetterSpacing9 = 2 << 1 // render the font 9 pixels wide, as VGA did
+	letterSpacingMask  = 3 << 1
+	FlagAspectLegacy   = 1 << 3 // stretch pixels vertically as on a CRT
+	FlagAspectSquare   = 2 << 3 // square pixels
+)
+
+// Sauce is the metadata record appended to ANSI art files. Text fields are
+// stored in CP437 and truncated to their fixed sizes when written.
+type Sauce struct {
+	Title    string // up to 35 characters
+	Author   string // up to 20 characters
+	Group    string // up to 20 characters
+	Date     time.Time
+	FileSize uint32 // size of the content before the EOF marker
+	DataType byte
+	FileType byte
+	TInfo1   uint16 // character data: width in columns
+	TInfo2   uint16 // character data: number of lines
+	TInfo3   uint16
+	TInfo4   uint16
+	Comments []string // up to 255 lines of 64 characters
+	Flags    byte
+	Font     string // TInfoS, e.g. "IBM VGA"
+}
+
+// MarshalBinary returns the bytes appended to a file's content: the EOF
+// marker, the optional comment block and the 128-byte record
+func (s Sauce) MarshalBinary() ([]byte, error) {
+	if len(s.Comments) > maxComments {
+		return nil, fmt.Errorf("sauce: %d comment lines, at most %d allowed", len(s.Comments), maxComments)
+	}
+	var buf bytes.Buffer
+	buf.WriteByte(eofMarker)
+	if len(s.Comments) > 0 {
+		buf.WriteString("COMNT")
+		for _, line := range s.Comments {
+			buf.Write(padded(line, commentLineLen, ' '))
+		}
+	}
+
+	buf.WriteString("SAUCE00")
+	buf.Write(padded(s.Title, 35, ' '))
+	buf.Write(padded(s.Author, 20, ' '))
+	buf.Write(padded(s.Group, 20, ' '))
+	date := "        "
+	if !s.Date.IsZero() {
+		date = s.Date.Format(sauceDateForm)
+	}
+	buf.WriteString(date)
+	binary.Write(&buf, binary.LittleEndian, s.FileSize)
+	buf.WriteByte(s.DataType)
+	buf.WriteByte(s.FileType)
+	binary.Write(&buf, binary.LittleEndian, [4]uint16{s.TInfo1, s.TInfo2, s.TInfo3, s.TInfo4})
+	buf.WriteByte(byte(len(s.Comments)))
+	buf.WriteByte(s.Flags)
+	buf.Write(padded(s.Font, 22, 0))
+	return buf.Bytes(), nil
+}
+
+// ParseSauce splits data into its content and SAUCE record. Data without a
+// record is returned unchanged with a nil record. The content excludes the
+// EOF marker and comment block.
+func ParseSauce(data []byte) ([]byte, *Sauce, error) {
+	if len(data) < sauceSize || !bytes.HasPrefix(data[len(data)-sauceSize:], []byte("SAUCE")) {
+		return trimEOF(data), nil, nil
+	}
+	rec := data[len(data)-sauceSize:]
+	if string(rec[5:7]) != "00" {
+		return nil, nil, fmt.Errorf("sauce: unsupported version %q", rec[5:7])
+	}
+	s := &Sauce{
+		Title:    field(rec[7:42]),
+		Author:   field(rec[42:62]),
+		Group:    field(rec[62:82]),
+		FileSize: binary.LittleEndian.Uint32(rec[90:94]),
+		DataType: rec[94],
+		FileType: rec[95],
+		TInfo1:   binary.LittleEndian.Uint16(rec[96:98]),
+		TInfo2:   binary.LittleEndian.Uint16(rec[98:100]),
+		TInfo3:   binary.LittleEndian.Uint16(rec[100:102]),
+		TInfo4:   binary.LittleEndian.Uint16(rec[102:104]),
+		Flags:    rec[105],
+		Font:     field(rec[106:128]),
+	}
+	// An unparseable date is common in the wild and not fatal
+	if d, err := time.Parse(sauceDateForm, string(rec[82:90])); err == nil {
+		s.Date = d
+	}
+
+	content := data[:len(data)-sauceSize]
+	if n := int(rec[104]); n > 0 {
+		start := len(content) - 5 - n*commentLineLen
+		if start < 0 || string(content[start:start+5]) != "COMNT" {
+			return nil, nil, fmt.Errorf("sauce: missing comment block")
+		}
+		for i := 0; i < n; i++ {
+			off := start + 5 + i*commentLineLen
+			s.Comments = append(s.Comments, field(content[off:off+commentLineLen]))
+		}
+		content = content[:start]
+	}
+	return trimEOF(content), s, nil
+}
+
+// padded encodes s in CP437, truncated or padded with pad to n bytes
+func padded(s string, n int, pad byte) []byte {
+	b := EncodeCP437(s)
+	if len(b) > n {
+		return b[:n]
+	}
+	return append(b, bytes.Repeat([]byte{pad}, n-len(b))...)
+}
+
+func field(b []byte) string {
+	return DecodeCP437(bytes.TrimRight(b, " \x00"))
+}
+
+// trimEOF drops everything from the first EOF marker, which DOS viewers
+// treat as the end of the drawing
+func trimEOF(data []byte) []byte {
+	if i := bytes.IndexByte(data, eofMarker); i >= 0 {
+		return data[:i]
+	}
+	return data
+}
@@ -0,0 +1,70 @@
+package ansi
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestSauceRoundTrip(t *testing.T) {
+	content := []byte("\x1b[0;1;37m\xdb\xdb\r\n")
+	in := Sauce{
+		Title:    "Café",
+		Author:   "an author with a name longer than twenty",
+		Group:    "grp",
+		Date:     time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC),
+		FileSize: uint32(len(content)),
+		DataType: DataTypeCharacter,
+		FileType: FileTypeANSI,
+		TInfo1:   80,
+		TInfo2:   25,
+		Comments: []string{"first line", "second line"},
+		Flags:    FlagLetterSpacing9 | FlagAspectLegacy,
+		Font:     "IBM VGA",
+	}
+	record, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	if want := 1 + 5 + 2*64 + 128; len(record) != want {
+		t.Fatalf("Record is %d bytes, want %d", len(record), want)
+	}
+
+	gotContent, out, err := ParseSauce(append(append([]byte{}, content...), record...))
+	if err != nil {
+		t.Fatalf("ParseSauce() error = %v", err)
+	}
+	if !bytes.Equal(gotContent, content) {
+		t.Errorf("Content = %q, want %q", gotContent, content)
+	}
+	if out == nil {
+		t.Fatal("ParseSauce() found no record")
+	}
+	if out.Title != "Café" || out.Author != "an author with a nam" || out.Group != "grp" || out.Font != "IBM VGA" {
+		t.Errorf("Text fields = %q %q %q %q", out.Title, out.Author, out.Group, out.Font)
+	}
+	if !out.Date.Equal(in.Date) || out.FileSize != in.FileSize || out.TInfo1 != 80 || out.TInfo2 != 25 || out.Flags != in.Flags {
+		t.Errorf("Record = %+v, want %+v", out, in)
+	}
+	if len(out.Comments) != 2 || out.Comments[1] != "second line" {
+		t.Errorf("Comments = %q", out.Comments)
+	}
+}
+
+func TestParseSauceWithoutRecord(t *testing.T) {
+	content, s, err := ParseSauce([]byte("hello\x1atrailing junk"))
+	if err != nil || s != nil {
+		t.Fatalf("ParseSauce() = %v, %v, want no record", s, err)
+	}
+	if string(content) != "hello" {
+		t.Errorf("Content = %q, want text before the EOF marker", content)
+	}
+}
+
+func TestParseSauceMissingComments(t *testing.T) {
+	record, _ := Sauce{}.MarshalBinary()
+	record[len(record)-128+104] = 3 // claims comment lines that are not there
+	if _, _, err := ParseSauce(record); err == nil {
+		t.Error("Expected error for missing comment block")
+	}
+}
@@ -0,0 +1,353 @@
+package ansi
+
+import (
+	"unicode/utf8"
+)
+
+// Parser limits. Escape sequences come from untrusted files, so counts and
+// coordinates are clamped and over-long sequences are abandoned.
+const (
+	DefaultMaxRows    = 1000
+	MaxColumns        = 400
+	maxParams         = 16
+	maxParamValue     = 9999
+	maxSequenceLength = 64
+)
+
+// Cell is one character on the screen with its resolved palette colours
+type Cell struct {
+	Rune rune
+	Fg   uint8 // 0-15, bold already applied
+	Bg   uint8 // 0-7, or 0-15 with iCE colours
+}
+
+var blankCell = Cell{Rune: ' ', Fg: 7, Bg: 0}
+
+// Screen is a virtual text screen holding the result of parsing ANSI art
+type Screen struct {
+	Width  int
+	Height int    // rows written, at least 1
+	Cells  []Cell // row-major, Width*Height
+	Sauce  *Sauce // nil if the file has no SAUCE record
+
+	// Truncated is set when drawing went past ParseOptions.MaxRows
+	Truncated bool
+}
+
+// At returns the cell at column x, row y
+func (s *Screen) At(x, y int) Cell {
+	return s.Cells[y*s.Width+x]
+}
+
+// ParseOptions bounds the screen a file may draw on
+type ParseOptions struct {
+	Width   int // columns when the file has no SAUCE width; 0 means 80
+	MaxRows int // 0 means DefaultMaxRows
+}
+
+// Parse interprets ANSI art: CP437 text (or UTF-8, if the content is valid
+// UTF-8 with non-ASCII characters), SGR colours, cursor movement, clears and
+// a trailing SAUCE record. Unsupported and malformed sequences are dropped.
+func Parse(data []byte, opts ParseOptions) (*Screen, error) {
+	content, sauce, err := ParseSauce(data)
+	if err != nil {
+		return nil, err
+	}
+	width := opts.Width
+	iceColors := false
+	if sauce != nil && sauce.DataType == DataTypeCharacter {
+		if sauce.TInfo1 > 0 {
+			width = int(sauce.TInfo1)
+		}
+		iceColors = sauce.Flags&FlagNonBlink != 0
+	}
+	if width <= 0 {
+		width = Columns
+	}
+	width = min(width, MaxColumns)
+	maxRows := opts.MaxRows
+	if maxRows <= 0 {
+		maxRows = DefaultMaxRows
+	}
+
+	p := &parser{
+		screen:    &Screen{Width: width, Sauce: sauce},
+		maxRows:   maxRows,
+		iceColors: iceColors,
+		fg:        7,
+		utf8:      isUTF8Text(content),
+	}
+	p.run(content)
+	s := p.screen
+	s.Height = max(1, p.rows)
+	if len(s.Cells) < s.Width*s.Height {
+		p.grow(s.Height - 1)
+	}
+	s.Cells = s.Cells[:s.Width*s.Height]
+	return s, nil
+}
+
+func isUTF8Text(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, c := range b {
+		if c >= 0x80 {
+			return true
+		}
+	}
+	return false
+}
+
+type parser struct {
+	screen    *Screen
+	maxRows   int
+	iceColors bool
+	utf8      bool
+
+	x, y        int
+	savedX      int
+	savedY      int
+	rows        int // rows touched so far
+	fg, bg      int
+	bold, blink bool
+	inverse     bool
+	stopDrawing bool
+}
+
+func (p *parser) run(b []byte) {
+	for i := 0; i < len(b) && !p.stopDrawing; {
+		c := b[i]
+		switch c {
+		case 0x1b:
+			i += p.escape(b[i:])
+			continue
+		case '\r':
+			p.x = 0
+		case '\n':
+			p.x = 0
+			p.moveTo(p.x, p.y+1)
+		case '\t':
+			p.x = min((p.x/8+1)*8, p.screen.Width-1)
+		default:
+			r, size := rune(c), 1
+			if p.utf8 {
+				r, size = utf8.DecodeRune(b[i:])
+			} else {
+				r = cp437Rune(c)
+			}
+			p.put(r)
+			i += size
+			continue
+		}
+		i++
+	}
+}
+
+// escape handles the sequence at the start of b and returns its length
+func (p *parser) escape(b []byte) int {
+	if len(b) < 2 {
+		return len(b)
+	}
+	switch b[1] {
+	case '[':
+	case ']':
+		// Operating system command (titles, hyperlinks): drop it up to BEL
+		// or ST
+		for i := 2; i < len(b) && i < maxSequenceLength*4; i++ {
+			if b[i] == 0x07 {
+				return i + 1
+			}
+			if b[i] == 0x1b && i+1 < len(b) && b[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return 2
+	default:
+		return 2
+	}
+
+	var params []int
+	cur, hasCur := 0, false
+	private := false
+	for i := 2; i < len(b); i++ {
+		if i >= maxSequenceLength {
+			return i
+		}
+		c := b[i]
+		switch {
+		case c >= '0' && c <= '9':
+			cur = min(cur*10+int(c-'0'), maxParamValue)
+			hasCur = true
+		case c == ';':
+			if len(params) < maxParams {
+				params = append(params, cur)
+			}
+			cur, hasCur = 0, false
+		case c >= '<' && c <= '?':
+			private = true
+		case c >= 0x40 && c <= 0x7e:
+			if hasCur && len(params) < maxParams {
+				params = append(params, cur)
+			}
+			if !private {
+				p.control(c, params)
+			}
+			return i + 1
+		case c >= 0x20 && c <= 0x2f:
+			// Intermediate bytes are not used by ANSI art
+			private = true
+		default:
+			// A control character aborts the sequence
+			return i
+		}
+	}
+	return len(b)
+}
+
+func param(params []int, i, def int) int {
+	if i < len(params) && params[i] > 0 {
+		return params[i]
+	}
+	return def
+}
+
+func (p *parser) control(final byte, params []int) {
+	switch final {
+	case 'm':
+		p.sgr(params)
+	case 'A':
+		p.moveTo(p.x, p.y-param(params, 0, 1))
+	case 'B':
+		p.moveTo(p.x, p.y+param(params, 0, 1))
+	case 'C':
+		p.moveTo(p.x+param(params, 0, 1), p.y)
+	case 'D':
+		p.moveTo(p.x-param(params, 0, 1), p.y)
+	case 'H', 'f':
+		p.moveTo(param(params, 1, 1)-1, param(params, 0, 1)-1)
+	case 'J':
+		switch param(params, 0, 0) {
+		case 0:
+			p.erase(p.x, p.y, p.screen.Width, p.y)
+			p.erase(0, p.y+1, p.screen.Width, p.rows)
+		case 1:
+			p.erase(0, 0, p.screen.Width, p.y-1)
+			p.erase(0, p.y, p.x+1, p.y)
+		default:
+			p.erase(0, 0, p.screen.Width, p.rows)
+			p.x, p.y = 0, 0
+		}
+	case 'K':
+		switch param(params, 0, 0) {
+		case 0:
+			p.erase(p.x, p.y, p.screen.Width, p.y)
+		case 1:
+			p.erase(0, p.y, p.x+1, p.y)
+		default:
+			p.erase(0, p.y, p.screen.Width, p.y)
+		}
+	case 's':
+		p.savedX, p.savedY = p.x, p.y
+	case 'u':
+		p.moveTo(p.savedX, p.savedY)
+	}
+}
+
+func (p *parser) sgr(params []int) {
+	if len(params) == 0 {
+		params = []int{0}
+	}
+	for _, n := range params {
+		switch {
+		case n == 0:
+			p.fg, p.bg = 7, 0
+			p.bold, p.blink, p.inverse = false, false, false
+		case n == 1:
+			p.bold = true
+		case n == 5 || n == 6:
+			p.blink = true
+		case n == 7:
+			p.inverse = true
+		case n == 22:
+			p.bold = false
+		case n == 25:
+			p.blink = false
+		case n == 27:
+			p.inverse = false
+		case n >= 30 && n <= 37:
+			p.fg = n - 30
+		case n == 39:
+			p.fg = 7
+		case n >= 40 && n <= 47:
+			p.bg = n - 40
+		case n == 49:
+			p.bg = 0
+		case n >= 90 && n <= 97:
+			p.fg = n - 90 + 8
+		case n >= 100 && n <= 107:
+			p.bg = n - 100 + 8
+		}
+	}
+}
+
+// moveTo places the cursor, clamped to the screen. The cursor may rest on
+// the row below the last; the screen is only truncated if something is
+// drawn there.
+func (p *parser) moveTo(x, y int) {
+	p.x = max(0, min(x, p.screen.Width-1))
+	p.y = max(0, min(y, p.maxRows))
+}
+
+func (p *parser) put(r rune) {
+	if r < 0x20 || r == 0x7f {
+		// Remaining control characters have no effect on the screen
+		return
+	}
+	if p.y >= p.maxRows {
+		p.screen.Truncated = true
+		p.stopDrawing = true
+		return
+	}
+	fg, bg := p.fg, p.bg
+	if p.bold && fg < 8 {
+		fg += 8
+	}
+	if p.blink && p.iceColors && bg < 8 {
+		bg += 8
+	}
+	if p.inverse {
+		fg, bg = bg, fg
+	}
+	if !p.iceColors {
+		// Without iCE colours the high background bit is blink
+		bg &= 7
+	}
+	p.grow(p.y)
+	p.screen.Cells[p.y*p.screen.Width+p.x] = Cell{Rune: r, Fg: uint8(fg), Bg: uint8(bg)}
+	p.rows = max(p.rows, p.y+1)
+
+	p.x++
+	if p.x >= p.screen.Width {
+		p.x = 0
+		p.moveTo(0, p.y+1)
+	}
+}
+
+// erase blanks the cells from (x0, y0) up to but excluding column x1 on
+// each row through y1
+func (p *parser) erase(x0, y0, x1, y1 int) {
+	for y := max(0, y0); y <= y1 && y < p.rows; y++ {
+		for x := max(0, x0); x < x1 && x < p.screen.Width; x++ {
+			p.screen.Cells[y*p.screen.Width+x] = blankCell
+		}
+		x0 = 0
+	}
+}
+
+// grow extends the screen so row y exists
+func (p *parser) grow(y int) {
+	for len(p.screen.Cells) < (y+1)*p.screen.Width {
+		p.screen.Cells = append(p.screen.Cells, blankCell)
+	}
+}
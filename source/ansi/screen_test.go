@@ -0,0 +1,149 @@
+package ansi
+
+import (
+	"strings"
+	"testing"
+)
+
+// rows returns the screen's characters, one string per row
+func rows(s *Screen) []string {
+	out := make([]string, s.Height)
+	for y := range out {
+		var sb strings.Builder
+		for x := 0; x < s.Width; x++ {
+			sb.WriteRune(s.At(x, y).Rune)
+		}
+		out[y] = strings.TrimRight(sb.String(), " ")
+	}
+	return out
+}
+
+func TestParseText(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		width    int
+		expected []string
+	}{
+		{"CRLF lines", "ab\r\ncd\r\n", 10, []string{"ab", "cd"}},
+		{"LF only", "ab\ncd", 10, []string{"ab", "cd"}},
+		{"Wraps at width", "abcdef", 4, []string{"abcd", "ef"}},
+		{"Tab", "a\tb", 20, []string{"a       b"}},
+		{"CP437 blocks", "\xdb\xb0\xdc", 10, []string{"█░▄"}},
+		{"CP437 control glyph", "\x03", 10, []string{"♥"}},
+		{"UTF-8 stream", "█░▄", 10, []string{"█░▄"}},
+		{"Cursor forward", "a\x1b[3Cb", 10, []string{"a   b"}},
+		{"Cursor position", "\x1b[2;3Hx\x1b[1;1Hy", 10, []string{"y", "  x"}},
+		{"Cursor up and back", "abc\r\ndef\x1b[A\x1b[2Dz", 10, []string{"azc", "def"}},
+		{"Save and restore", "a\x1b[sbc\x1b[uX", 10, []string{"aXc"}},
+		{"Erase line", "abcdef\x1b[3D\x1b[K", 10, []string{"abc"}},
+		{"Clear screen", "abc\r\ndef\x1b[2Jx", 10, []string{"x", ""}},
+		{"Unknown and private sequences", "a\x1b[?7hb\x1b[5nc\x1b]0;title\x07d", 10, []string{"abcd"}},
+		{"Sequence aborted by control", "a\x1b[3\nb", 10, []string{"a", "b"}},
+		{"Stops at EOF marker", "ab\x1acd", 10, []string{"ab"}},
+		{"Empty", "", 10, []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse([]byte(tt.input), ParseOptions{Width: tt.width})
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got := rows(s)
+			if strings.Join(got, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("rows = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseColors(t *testing.T) {
+	s, err := Parse([]byte("\x1b[1;31;44mA\x1b[0mB\x1b[7;32mC\x1b[0;5;41mD\x1b[92;103mE"), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	tests := []struct {
+		x      int
+		fg, bg uint8
+	}{
+		{0, 9, 4}, // bold red on blue
+		{1, 7, 0}, // reset
+		{2, 0, 2}, // inverse green
+		{3, 7, 1}, // blink without iCE keeps the dark background
+		{4, 10, 3}, // bright backgrounds need iCE colours
+	}
+	for _, tt := range tests {
+		if c := s.At(tt.x, 0); c.Fg != tt.fg || c.Bg != tt.bg {
+			t.Errorf("Cell %d = fg %d bg %d, want fg %d bg %d", tt.x, c.Fg, c.Bg, tt.fg, tt.bg)
+		}
+	}
+}
+
+func TestParseSauceWidthAndICE(t *testing.T) {
+	record, _ := Sauce{DataType: DataTypeCharacter, FileType: FileTypeANSI, TInfo1: 4, Flags: FlagNonBlink}.MarshalBinary()
+	data := append([]byte("\x1b[5;41mabcdef"), record...)
+	s, err := Parse(data, ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if s.Width != 4 || s.Height != 2 || s.Sauce == nil {
+		t.Fatalf("Screen = %dx%d, sauce %v; want 4x2 with SAUCE", s.Width, s.Height, s.Sauce)
+	}
+	if c := s.At(0, 0); c.Bg != 9 {
+		t.Errorf("Background = %d, want bright red with iCE colours", c.Bg)
+	}
+
+	// Bright backgrounds from aixterm codes and inverse bold also need iCE
+	// colours
+	for _, flags := range []byte{0, FlagNonBlink} {
+		record, _ := Sauce{DataType: DataTypeCharacter, FileType: FileTypeANSI, TInfo1: 4, Flags: flags}.MarshalBinary()
+		s, err := Parse(append([]byte("\x1b[103mA\x1b[0;1;7;34mB"), record...), ParseOptions{})
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		want := [2]uint8{11, 12}
+		if flags == 0 {
+			want = [2]uint8{3, 4}
+		}
+		if got := [2]uint8{s.At(0, 0).Bg, s.At(1, 0).Bg}; got != want {
+			t.Errorf("Flags %d: backgrounds = %v, want %v", flags, got, want)
+		}
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	// Runaway cursor movement and huge parameters stay within bounds
+	s, err := Parse([]byte("\x1b[99999;99999Hx\x1b[99999Ay"), ParseOptions{Width: 10, MaxRows: 20})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !s.Truncated || s.Height > 20 {
+		t.Errorf("Screen %dx%d truncated=%v, want truncated within 20 rows", s.Width, s.Height, s.Truncated)
+	}
+
+	s, err = Parse([]byte(strings.Repeat("x\n", 50)), ParseOptions{MaxRows: 10})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if s.Height != 10 || !s.Truncated {
+		t.Errorf("Height = %d, truncated=%v; want 10 and truncated", s.Height, s.Truncated)
+	}
+
+	// A line break after the last row drops nothing
+	s, err = Parse([]byte(strings.Repeat("x\r\n", 10)), ParseOptions{MaxRows: 10})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if s.Height != 10 || s.Truncated {
+		t.Errorf("Height = %d, truncated=%v; want 10 and not truncated", s.Height, s.Truncated)
+	}
+
+	s, err = Parse([]byte("a\x1b["+strings.Repeat("1;", 200)+"mb"), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := rows(s)[0]; !strings.HasPrefix(got, "a") {
+		t.Errorf("Row = %q after over-long sequence", got)
+	}
+}
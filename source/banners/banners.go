@@ -1,92 +1,577 @@
 package banners
 
 import (
+	"errors"
 	"fmt"
 	"image"
-	"path/filepath"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/MhunterDev/img2ascii/source/img2ascii"
 	"github.com/fogleman/gg"
 	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
 )
 
-const fontRoot = "source/banners/fonts"
+// Pixels per character cell when rasterizing text. Cells are twice as tall
+// as they are wide, like terminal characters.
+const (
+	cellWidth  = 8
+	cellHeight = 16
+)
+
+// Banner sizes used when Width or Height is not set
+const (
+	defaultBannerWidth  = 80
+	defaultBannerHeight = 8
+)
+
+// maxCanvasPixels limits the canvas TrueType banners are drawn on
+const maxCanvasPixels = 8 << 20
 
+// ErrBannerTooLarge is returned for banners whose lines would need a canvas
+// of more than maxCanvasPixels
+var ErrBannerTooLarge = errors.New("banner too large")
+
+// Font names a font in a FontRegistry
 type Font string
 
-func (f Font) Path() string {
-	return filepath.Join(fontRoot, string(f)+".ttf")
+// Alignment positions lines of a multi-line banner
+type Alignment int
+
+const (
+	AlignCenter Alignment = iota
+	AlignLeft
+	AlignRight
+)
+
+func (a Alignment) String() string {
+	switch a {
+	case AlignCenter:
+		return "center"
+	case AlignLeft:
+		return "left"
+	case AlignRight:
+		return "right"
+	default:
+		return fmt.Sprintf("Alignment(%d)", int(a))
+	}
+}
+
+// ParseAlignment returns the alignment named by String
+func ParseAlignment(name string) (Alignment, error) {
+	for a := AlignCenter; a <= AlignRight; a++ {
+		if a.String() == name {
+			return a, nil
+		}
+	}
+	return AlignCenter, fmt.Errorf("unknown alignment: %q", name)
 }
 
 type BannerOptions struct {
-	Font       Font
-	Reverse    bool
-	Characters string
-	Style      string
+	Font        Font
+	Reverse     bool   // TrueType fonts only; FIGlet fonts ignore it
+	Characters  string // ramp, darkest first; TrueType fonts only
+	Style       BannerStyle
+	Layout      LayoutMode // how FIGlet fonts join characters
+	Align       Alignment
+	LineSpacing float64 // distance between lines as a multiple of the line height; 0 or not finite means 1
+
+	// Fallback lists TrueType fonts to draw characters the font lacks,
+	// tried in order. FIGlet fonts have no fallback.
+	Fallback []Font
+
+	// Variation selects an instance of a variable TrueType font, such as
+	// Variation{AxisWeight: 700} for bold. Fallback fonts take the axes
+	// they have, limited to their ranges.
+	Variation Variation
+
+	// SelfFill draws each letter with its own character, so an H is built
+	// from H's. It replaces the ramp, so Characters and Reverse are ignored.
+	SelfFill bool
 }
 
+// Banner is text to render as ASCII art. Lines break at newlines and wrap
+// at word boundaries to fit at most Width columns. For TrueType fonts,
+// Height is the rows a capital letter spans; the output is trimmed to the
+// text, so it is usually narrower than Width, taller where letters descend
+// and grows with the number of lines. Letters are made smaller when a word
+// is wider than Width on its own.
 type Banner struct {
 	Message string
-	Path    string
+	Path    string // where RenderBanner writes the art, without the .txt extension
 	Width   int
 	Height  int
 	Options BannerOptions
+	Fonts   *FontRegistry // nil means DefaultFonts()
+}
+
+func (b *Banner) fonts() *FontRegistry {
+	if b.Fonts == nil {
+		return DefaultFonts()
+	}
+	return b.Fonts
+}
+
+func (b *Banner) font() Font {
+	if b.Options.Font == "" {
+		return DefaultFont
+	}
+	return b.Options.Font
+}
+
+func (b *Banner) lineSpacing() float64 {
+	if s := b.Options.LineSpacing; s <= 0 || math.IsNaN(s) || math.IsInf(s, 0) {
+		return 1
+	}
+	return b.Options.LineSpacing
+}
+
+// anchor returns the x coordinate and horizontal anchor that align a line
+// within width
+func (a Alignment) anchor(width float64) (x, ax float64) {
+	switch a {
+	case AlignLeft:
+		return 0, 0
+	case AlignRight:
+		return width, 1
+	default:
+		return width / 2, 0.5
+	}
+}
+
+// wrapText splits text into lines at newlines and wraps each at word
+// boundaries so it fits. A word that does not fit on its own gets a line to
+// itself. Blank lines are kept.
+func wrapText(text string, fits func(string) bool) []string {
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			if line == "" {
+				line = word
+			} else if candidate := line + " " + word; fits(candidate) {
+				line = candidate
+			} else {
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// capHeightFace returns a face for the instance v of the named font whose
+// capital letters are height pixels tall, falling back to ascent plus
+// descent for fonts without an H
+func (b *Banner) capHeightFace(name Font, height float64, v Variation) (font.Face, error) {
+	const probeSize = 100
+	probe, err := b.fonts().FaceVariation(name, probeSize, v)
+	if err != nil {
+		return nil, err
+	}
+	bounds, _, ok := probe.GlyphBounds('H')
+	m := probe.Metrics()
+	probe.Close()
+	size := float64(bounds.Max.Y-bounds.Min.Y) / 64
+	if !ok || size <= 0 {
+		size = float64(m.Ascent+m.Descent) / 64
+	}
+	return b.fonts().FaceVariation(name, probeSize*height/size, v)
+}
+
+// face returns the face text is drawn with: the banner's font, then each
+// of its fallback fonts for runes the ones before lack, all at the
+// banner's variation and sized to the same capital height
+func (b *Banner) face(height float64) (font.Face, error) {
+	var faces fallbackFace
+	for i, name := range append([]Font{b.font()}, b.Options.Fallback...) {
+		v := b.Options.Variation
+		if i > 0 {
+			v = b.fonts().clampVariation(name, v)
+		}
+		face, err := b.capHeightFace(name, height, v)
+		if err != nil {
+			faces.Close()
+			return nil, err
+		}
+		faces = append(faces, face)
+	}
+	if len(faces) == 1 {
+		return faces[0], nil
+	}
+	return faces, nil
 }
 
-func (b *Banner) renderToImage() (string, error) {
-	outPath := b.Path
+// layout returns a face whose capitals are height pixels tall and the
+// message wrapped to maxWidth pixels in it, with the width of the widest
+// line
+func (b *Banner) layout(height, maxWidth float64) (font.Face, []string, float64, error) {
+	face, err := b.face(height)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	dc := gg.NewContext(1, 1)
+	dc.SetFontFace(face)
+	lines := wrapText(b.Message, func(s string) bool {
+		w, _ := dc.MeasureString(s)
+		return w <= maxWidth
+	})
+	textWidth := 0.0
+	for _, line := range lines {
+		w, _ := dc.MeasureString(line)
+		textWidth = max(textWidth, w)
+	}
+	return face, lines, textWidth, nil
+}
+
+// letterBox is the area a letter of the message covers in the character
+// grid, in cells
+type letterBox struct {
+	letter rune
+	x0, x1 float64
+	y0, y1 float64
+}
+
+// appendLetterBoxes adds the boxes of the letters of a line drawn at x
+// with horizontal anchor ax, in canvas pixels. Advances and kerning are
+// summed in one pass, as MeasureString does, and the boxes shifted once
+// the line's width is known.
+func appendLetterBoxes(boxes []letterBox, face font.Face, line string, x, ax, top, lineHeight float64) []letterBox {
+	first := len(boxes)
+	var pos fixed.Int26_6
+	prev := rune(-1)
+	for _, r := range line {
+		if prev >= 0 {
+			pos += face.Kern(prev, r)
+		}
+		a, _ := face.GlyphAdvance(r)
+		if r != ' ' {
+			boxes = append(boxes, letterBox{letter: r, x0: float64(pos) / 64, x1: float64(pos+a) / 64, y0: top, y1: top + lineHeight})
+		}
+		pos += a
+		prev = r
+	}
+	left := cellWidth + x - ax*float64(pos)/64
+	for i := first; i < len(boxes); i++ {
+		boxes[i].x0 += left
+		boxes[i].x1 += left
+	}
+	return boxes
+}
+
+// renderToImage draws the wrapped lines on a canvas of cellWidth by
+// cellHeight pixels per character, trims the blank margins and sets
+// b.Width and b.Height to the character grid that covers what is left. It
+// returns the trimmed image and, for self-filled banners, where each letter
+// falls in the grid.
+func (b *Banner) renderToImage() (*image.RGBA, []letterBox, error) {
 	if b.Width <= 0 {
-		b.Width = 80
+		b.Width = defaultBannerWidth
 	}
 	if b.Height <= 0 {
-		b.Height = 10
+		b.Height = defaultBannerHeight
+	}
+	maxWidth := float64(b.Width * cellWidth)
+	face, lines, textWidth, err := b.layout(float64(b.Height*cellHeight), maxWidth)
+	if err == nil && textWidth > maxWidth {
+		// A word wider than the banner: shrink the letters to fit it
+		// rather than squashing them into the columns afterwards
+		face.Close()
+		face, lines, textWidth, err = b.layout(float64(b.Height*cellHeight)*maxWidth/textWidth, maxWidth)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load font: %w", err)
+	}
+	defer face.Close()
+	m := face.Metrics()
+	lineHeight := float64(m.Ascent+m.Descent) / 64
+
+	// Pad by a cell on each side for ink outside the advance, such as
+	// italic overhangs; the margins are trimmed afterwards
+	step := lineHeight * b.lineSpacing()
+	imgWidth := int(math.Ceil(textWidth)) + 2*cellWidth
+	imgHeight := int(math.Ceil(step*float64(len(lines)-1)+lineHeight)) + 2*cellHeight
+	if imgHeight > maxCanvasPixels/imgWidth {
+		return nil, nil, fmt.Errorf("%w: %d lines at %d rows", ErrBannerTooLarge, len(lines), b.Height)
 	}
-	imgWidth := 5 + (b.Width * 16)
-	imgHeight := 5 + (b.Height * 16)
 	dc := gg.NewContext(imgWidth, imgHeight)
 	dc.SetRGB(1, 1, 1)
 	dc.Clear()
-	fontSize := float64(imgHeight) * 0.8
-	fontPath := b.Options.Font.Path()
-	if err := dc.LoadFontFace(fontPath, fontSize); err != nil {
-		return "", fmt.Errorf("failed to load font: %w", err)
-	}
+	dc.SetFontFace(face)
 	dc.SetRGB(0, 0, 0)
-	dc.DrawStringAnchored(b.Message, float64(imgWidth)/2, float64(imgHeight)/2, 0.5, 0.5)
-	pngPath := outPath + ".png"
-	if err := dc.SavePNG(pngPath); err != nil {
-		return "", err
+	ascent := float64(m.Ascent) / 64
+	x, ax := b.Options.Align.anchor(textWidth)
+	var boxes []letterBox
+	for i, line := range lines {
+		top := cellHeight + float64(i)*step
+		dc.DrawStringAnchored(line, cellWidth+x, top+ascent, ax, 0)
+
+		if b.Options.SelfFill {
+			boxes = appendLetterBoxes(boxes, face, line, x, ax, top, lineHeight)
+		}
+	}
+
+	img := dc.Image().(*image.RGBA)
+	ink := inkBounds(img)
+	if ink.Empty() {
+		ink = img.Bounds()
 	}
-	return pngPath, nil
+	// Ink overhanging the advances may still exceed Width; scale both
+	// dimensions alike so letters keep their shape
+	scale := min(1, float64(b.Width*cellWidth)/float64(ink.Dx()))
+	b.Width = max(1, int(math.Round(float64(ink.Dx())*scale/cellWidth)))
+	b.Height = max(1, int(math.Round(float64(ink.Dy())*scale/cellHeight)))
+
+	// Map the boxes from canvas pixels to the trimmed grid
+	sx := float64(b.Width) / float64(ink.Dx())
+	sy := float64(b.Height) / float64(ink.Dy())
+	for i := range boxes {
+		bx := &boxes[i]
+		bx.x0, bx.x1 = (bx.x0-float64(ink.Min.X))*sx, (bx.x1-float64(ink.Min.X))*sx
+		bx.y0, bx.y1 = (bx.y0-float64(ink.Min.Y))*sy, (bx.y1-float64(ink.Min.Y))*sy
+	}
+
+	trimmed := image.NewRGBA(image.Rect(0, 0, ink.Dx(), ink.Dy()))
+	xdraw.Copy(trimmed, image.Point{}, img, ink, xdraw.Src, nil)
+	return trimmed, boxes, nil
 }
 
-func (b *Banner) resizeRGBA(pngPath string) (*image.RGBA, error) {
-	src, err := gg.LoadPNG(pngPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load image: %v", err)
+// selfFill replaces each ink cell of rows with the letter whose box is
+// nearest its centre on the same line
+func selfFill(rows []string, boxes []letterBox) []string {
+	filled := make([]string, len(rows))
+	for y, row := range rows {
+		cells := []rune(row)
+		cy := float64(y) + 0.5
+		for x, c := range cells {
+			if c == ' ' {
+				continue
+			}
+			cx := float64(x) + 0.5
+			best := math.Inf(1)
+			for _, bx := range boxes {
+				if cy < bx.y0 || cy >= bx.y1 {
+					continue
+				}
+				if d := max(bx.x0-cx, cx-bx.x1, 0); d < best {
+					best, cells[x] = d, bx.letter
+				}
+			}
+		}
+		filled[y] = string(cells)
+	}
+	return filled
+}
+
+// inkBounds returns the smallest rectangle holding every pixel that is not
+// white
+func inkBounds(img *image.RGBA) image.Rectangle {
+	var ink image.Rectangle
+	r := img.Bounds()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			if c.R != 0xff || c.G != 0xff || c.B != 0xff {
+				ink = ink.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
 	}
+	return ink
+}
+
+func (b *Banner) resizeRGBA(src image.Image) *image.RGBA {
 	dst := image.NewRGBA(image.Rect(0, 0, b.Width, b.Height))
 	xdraw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, src.Bounds(), xdraw.Over, nil)
-	return dst, nil
+	return dst
 }
 
-func RenderBanner(b Banner) error {
-	pngPath, err := b.renderToImage()
+// renderFIGlet lays the message out in a FIGlet font, which needs no
+// rasterizing. Lines are wrapped to Width columns when it is set and
+// aligned within the widest line.
+func (b *Banner) renderFIGlet(f *FIGletFont) []string {
+	width := func(rows []string) int {
+		w := 0
+		for _, row := range rows {
+			w = max(w, utf8.RuneCountInString(strings.TrimRight(row, " ")))
+		}
+		return w
+	}
+	lines := wrapText(b.Message, func(s string) bool {
+		return b.Width <= 0 || width(f.Render(s, b.Options.Layout)) <= b.Width
+	})
+	blocks := make([][]string, len(lines))
+	blockWidth := 0
+	for i, line := range lines {
+		blocks[i] = f.Render(line, b.Options.Layout)
+		blockWidth = max(blockWidth, width(blocks[i]))
+	}
+	gap := max(0, int(math.Round(float64(f.Height)*(b.lineSpacing()-1))))
+
+	var rows []string
+	for i, block := range blocks {
+		if i > 0 {
+			rows = append(rows, make([]string, gap)...)
+		}
+		if b.Options.SelfFill {
+			_, owners := f.render(lines[i], b.Options.Layout)
+			block = ownerFill(block, owners)
+		}
+		w := width(block)
+		for _, row := range block {
+			pad := 0
+			switch b.Options.Align {
+			case AlignCenter:
+				pad = (blockWidth - w) / 2
+			case AlignRight:
+				pad = blockWidth - w
+			}
+			rows = append(rows, strings.TrimRight(strings.Repeat(" ", pad)+row, " "))
+		}
+	}
+	return rows
+}
+
+// ownerFill replaces each ink cell of rows with the character that drew it
+func ownerFill(rows []string, owners [][]rune) []string {
+	filled := make([]string, len(rows))
+	for y, row := range rows {
+		cells := []rune(row)
+		for x, c := range cells {
+			if c != ' ' && y < len(owners) && x < len(owners[y]) {
+				cells[x] = owners[y][x]
+			}
+		}
+		filled[y] = string(cells)
+	}
+	return filled
+}
+
+// renderTrueType rasterizes the message and converts it to characters,
+// returning the rows, the ramp they were drawn with and the raster
+func (b *Banner) renderTrueType() ([]string, string, image.Image, error) {
+	img, boxes, err := b.renderToImage()
 	if err != nil {
-		return fmt.Errorf("failed to render banner: %w", err)
+		return nil, "", nil, fmt.Errorf("failed to render banner: %w", err)
 	}
-	resizedImg, err := b.resizeRGBA(pngPath)
+	options := img2ascii.ConversionOptions{
+		Mode:       img2ascii.ModeBanner,
+		Reverse:    b.Options.Reverse && !b.styledReverse(),
+		Characters: b.Options.Characters,
+		Layout:     img2ascii.Layout{Fit: img2ascii.FitContain, Width: b.Width, Height: b.Height},
+	}
+	if b.Options.SelfFill {
+		options.Reverse, options.Characters = false, ""
+	}
+	art, err := img2ascii.ConvertImage(b.resizeRGBA(img), options)
 	if err != nil {
-		return fmt.Errorf("failed to resize banner image: %w", err)
+		return nil, "", nil, fmt.Errorf("failed to convert image to ASCII: %w", err)
 	}
-	resizedPngPath := pngPath + ".resized.png"
-	if err := gg.SavePNG(resizedPngPath, resizedImg); err != nil {
-		return fmt.Errorf("failed to save resized image: %w", err)
+	if b.Options.SelfFill {
+		return selfFill(art.Rows, boxes), art.Ramp, img, nil
 	}
-	asciiPath := b.Path + ".txt"
-	err = img2ascii.RunBanner(resizedPngPath, asciiPath, b.Width, b.Height)
+	return art.Rows, art.Ramp, img, nil
+}
+
+// styledReverse reports whether a reversed TrueType banner has a style.
+// Styles are drawn around the letters, so such banners are converted
+// unreversed, styled, and inverted afterwards.
+func (b *Banner) styledReverse() bool {
+	if _, err := b.fonts().FIGlet(b.font()); err == nil {
+		return false
+	}
+	return b.Options.Reverse && !b.Options.SelfFill && b.Options.Style != StylePlain
+}
+
+// ErrNoImage is returned by WritePNG for banners that were not rasterized,
+// such as those in FIGlet fonts
+var ErrNoImage = errors.New("banner has no image")
+
+// BannerResult is a rendered banner
+type BannerResult struct {
+	Rows  []string
+	Image image.Image // the text as rasterized before conversion; nil for FIGlet fonts
+}
+
+// String returns the rows, each ending in a newline
+func (r *BannerResult) String() string {
+	var sb strings.Builder
+	for _, row := range r.Rows {
+		sb.WriteString(row)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// WritePNG encodes the rasterized text as a PNG
+func (r *BannerResult) WritePNG(w io.Writer) error {
+	if r.Image == nil {
+		return ErrNoImage
+	}
+	return png.Encode(w, r.Image)
+}
+
+// MissingRunes returns the characters of the message, other than spaces
+// and line breaks, that neither the banner's font nor, for TrueType fonts,
+// its fallbacks can draw, in the order they first appear. Render draws
+// them as the font's missing-glyph box or, in FIGlet fonts, skips them.
+func (b *Banner) MissingRunes() ([]rune, error) {
+	chain := []Font{b.font()}
+	if _, err := b.fonts().FIGlet(b.font()); err != nil {
+		chain = append(chain, b.Options.Fallback...)
+	}
+	var missing []rune
+	seen := make(map[rune]bool)
+	for _, c := range b.Message {
+		if c == ' ' || c == '\n' || seen[c] {
+			continue
+		}
+		seen[c] = true
+		found := false
+		for _, name := range chain {
+			ok, err := b.fonts().HasGlyph(name, c)
+			if err != nil {
+				return nil, err
+			}
+			if found = ok; found {
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, c)
+		}
+	}
+	return missing, nil
+}
+
+// Render renders b in its font and style in memory. b.Path is not used.
+func Render(b Banner) (*BannerResult, error) {
+	result := &BannerResult{}
+	ramp := ""
+	if f, err := b.fonts().FIGlet(b.font()); err == nil {
+		result.Rows = b.renderFIGlet(f)
+	} else if result.Rows, ramp, result.Image, err = b.renderTrueType(); err != nil {
+		return nil, err
+	}
+	result.Rows = applyStyle(result.Rows, b.Options.Style, ramp, b.Options.SelfFill)
+	if b.styledReverse() {
+		result.Rows = invertRows(result.Rows, ramp)
+	}
+	return result, nil
+}
+
+// RenderBanner renders b and writes the art to b.Path with a .txt
+// extension
+func RenderBanner(b Banner) error {
+	result, err := Render(b)
 	if err != nil {
-		return fmt.Errorf("failed to convert image to ASCII: %w", err)
+		return err
 	}
-	return nil
+	return os.WriteFile(b.Path+".txt", []byte(result.String()), 0644)
 }
@@ -0,0 +1,299 @@
+package banners
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWrapText(t *testing.T) {
+	fits := func(s string) bool { return len(s) <= 10 }
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello", []string{"Hello"}},
+		{"Hello big world", []string{"Hello big", "world"}},
+		{"one\ntwo three", []string{"one", "two three"}},
+		{"a\n\nb", []string{"a", "", "b"}},
+		{"extraordinarily long", []string{"extraordinarily", "long"}},
+		{"  spaced   out  ", []string{"spaced out"}},
+	}
+	for _, tt := range tests {
+		if got := wrapText(tt.text, fits); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("wrapText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseAlignment(t *testing.T) {
+	for a := AlignCenter; a <= AlignRight; a++ {
+		if got, err := ParseAlignment(a.String()); err != nil || got != a {
+			t.Errorf("ParseAlignment(%q) = %v, %v", a.String(), got, err)
+		}
+	}
+	if _, err := ParseAlignment("justify"); err == nil {
+		t.Error("Expected error for unknown alignment")
+	}
+}
+
+func TestRenderBannerFIGletLines(t *testing.T) {
+	render := func(b Banner) []string {
+		t.Helper()
+		b.Path = filepath.Join(t.TempDir(), "banner")
+		b.Options.Font = "standard"
+		if err := RenderBanner(b); err != nil {
+			t.Fatalf("RenderBanner() error = %v", err)
+		}
+		data, err := os.ReadFile(b.Path + ".txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+
+	// "Hi" is 9 columns wide and "I" 5 in the standard font
+	left := render(Banner{Message: "Hi\nI", Options: BannerOptions{Align: AlignLeft}})
+	if len(left) != 12 || left[0] != " _   _ _" || left[6] != " ___" {
+		t.Errorf("Left aligned = %q", left)
+	}
+	right := render(Banner{Message: "Hi\nI", Options: BannerOptions{Align: AlignRight}})
+	if right[0] != " _   _ _" || right[6] != "     ___" {
+		t.Errorf("Right aligned = %q", right)
+	}
+	centered := render(Banner{Message: "Hi\nI", Width: 13})
+	if centered[0] != " _   _ _" || centered[6] != "   ___" {
+		t.Errorf("Centered = %q", centered)
+	}
+	spaced := render(Banner{Message: "Hi\nI", Options: BannerOptions{LineSpacing: 1.5}})
+	if len(spaced) != 15 {
+		t.Errorf("Spaced banner has %d lines, want 15", len(spaced))
+	}
+	if nan := render(Banner{Message: "Hi\nI", Options: BannerOptions{LineSpacing: math.NaN()}}); len(nan) != 12 {
+		t.Errorf("NaN spaced banner has %d lines, want 12", len(nan))
+	}
+	wrapped := render(Banner{Message: "Hi Hi", Width: 12})
+	if len(wrapped) != 12 {
+		t.Errorf("Wrapped banner has %d lines, want 12", len(wrapped))
+	}
+}
+
+func TestRenderBannerHeightGrowsWithLines(t *testing.T) {
+	rows := func(message string) int {
+		t.Helper()
+		b := Banner{Message: message, Path: filepath.Join(t.TempDir(), "banner"), Width: 40, Height: 4}
+		if err := RenderBanner(b); err != nil {
+			t.Fatalf("RenderBanner() error = %v", err)
+		}
+		data, err := os.ReadFile(b.Path + ".txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Count(string(data), "\n")
+	}
+	if one, three := rows("Hi"), rows("Hi\nthere\nyou"); three <= 2*one {
+		t.Errorf("Three lines gave %d rows, one line %d", three, one)
+	}
+}
+
+func TestRenderBannerFitsText(t *testing.T) {
+	render := func(message string) []string {
+		t.Helper()
+		b := Banner{Message: message, Path: filepath.Join(t.TempDir(), "banner"), Width: 100, Height: 5}
+		if err := RenderBanner(b); err != nil {
+			t.Fatalf("RenderBanner() error = %v", err)
+		}
+		data, err := os.ReadFile(b.Path + ".txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+
+	// Capitals without descenders span exactly the requested height, and
+	// the blank margins are trimmed
+	short := render("HI")
+	if len(short) != 5 {
+		t.Errorf("HI has %d rows, want 5:\n%s", len(short), strings.Join(short, "\n"))
+	}
+	width := len([]rune(short[0]))
+	left, right := false, false
+	for _, row := range short {
+		left = left || !strings.HasPrefix(row, " ")
+		right = right || !strings.HasSuffix(row, " ")
+	}
+	if width >= 100 || !left || !right {
+		t.Errorf("HI is not trimmed to %d columns:\n%s", width, strings.Join(short, "\n"))
+	}
+	if long := render("HI HI HI"); len([]rune(long[0])) <= width {
+		t.Errorf("Longer text is not wider: %d <= %d", len([]rune(long[0])), width)
+	}
+}
+
+func TestSelfFill(t *testing.T) {
+	boxes := []letterBox{
+		{letter: 'H', x0: 0, x1: 3, y0: 0, y1: 2},
+		{letter: 'i', x0: 3, x1: 5, y0: 0, y1: 2},
+		{letter: 'o', x0: 0, x1: 5, y0: 2, y1: 3},
+	}
+	rows := []string{"@ @ @", "@@@ :", " # ", "     @"}
+	want := []string{"H H i", "HHH i", " o ", "     @"}
+	if got := selfFill(rows, boxes); !reflect.DeepEqual(got, want) {
+		t.Errorf("selfFill() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderBannerOptions(t *testing.T) {
+	render := func(message string, o BannerOptions) string {
+		t.Helper()
+		b := Banner{Message: message, Path: filepath.Join(t.TempDir(), "banner"), Width: 40, Height: 4, Options: o}
+		if err := RenderBanner(b); err != nil {
+			t.Fatalf("RenderBanner() error = %v", err)
+		}
+		data, err := os.ReadFile(b.Path + ".txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	only := func(s, allowed string) bool {
+		return strings.Trim(s, allowed+"\n") == ""
+	}
+
+	if art := render("HI", BannerOptions{Characters: "Xx "}); !only(art, "Xx ") || !strings.Contains(art, "X") {
+		t.Errorf("Characters not used:\n%s", art)
+	}
+	// Trimmed to the ink, the banner starts inside the I
+	plain, reversed := render("I I", BannerOptions{}), render("I I", BannerOptions{Reverse: true})
+	if plain[0] != '@' || reversed[0] != ' ' || !strings.Contains(reversed, "@") {
+		t.Errorf("Reverse did not invert:\n%s\n%s", plain, reversed)
+	}
+	if art := render("HI", BannerOptions{SelfFill: true, Reverse: true}); !only(art, "HI ") {
+		t.Errorf("Self-fill not drawn in its letters:\n%s", art)
+	}
+	if art := render("HI", BannerOptions{Font: "standard", SelfFill: true}); !only(art, "HI ") || !strings.Contains(art, "H") {
+		t.Errorf("FIGlet self-fill not drawn in its letters:\n%s", art)
+	}
+}
+
+func TestRender(t *testing.T) {
+	dir := t.TempDir()
+	b := Banner{Message: "Hi", Path: filepath.Join(dir, "banner"), Width: 20, Height: 4}
+	result, err := Render(b)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if len(result.Rows) == 0 || result.Image == nil || result.Image.Bounds().Min != (image.Point{}) {
+		t.Fatalf("Render() = %+v", result)
+	}
+	var buf bytes.Buffer
+	if err := result.WritePNG(&buf); err != nil {
+		t.Fatalf("WritePNG() error = %v", err)
+	}
+	if _, err := png.Decode(&buf); err != nil {
+		t.Errorf("WritePNG() wrote an invalid PNG: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Render() wrote %d files", len(entries))
+	}
+
+	b.Options.Font = "standard"
+	result, err = Render(b)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if result.Image != nil || result.String() != " _   _ _\n| | | (_)\n| |_| | |\n|  _  | |\n|_| |_|_|\n\n" {
+		t.Errorf("Render() = %q", result.String())
+	}
+	if err := result.WritePNG(&buf); !errors.Is(err, ErrNoImage) {
+		t.Errorf("WritePNG() error = %v, want ErrNoImage", err)
+	}
+}
+
+func TestRenderBannerLineSpacingNotFinite(t *testing.T) {
+	for _, spacing := range []float64{math.NaN(), math.Inf(1)} {
+		b := Banner{Message: "Hi\nthere", Width: 20, Height: 4, Options: BannerOptions{LineSpacing: spacing}}
+		if _, err := Render(b); err != nil {
+			t.Errorf("Render() with line spacing %v error = %v", spacing, err)
+		}
+	}
+}
+
+func TestAppendLetterBoxes(t *testing.T) {
+	face, err := DefaultFonts().Face("SourceCodePro-Regular", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer face.Close()
+	adv, _ := face.GlyphAdvance('H')
+	w := float64(adv) / 64
+
+	// Right aligned at x, the three advances of "H I" end at the padding
+	// cell plus x; the space gets no box
+	boxes := appendLetterBoxes(nil, face, "H I", 100, 1, 5, 20)
+	want := []letterBox{
+		{letter: 'H', x0: cellWidth + 100 - 3*w, x1: cellWidth + 100 - 2*w, y0: 5, y1: 25},
+		{letter: 'I', x0: cellWidth + 100 - w, x1: cellWidth + 100, y0: 5, y1: 25},
+	}
+	if !reflect.DeepEqual(boxes, want) {
+		t.Errorf("appendLetterBoxes() = %+v, want %+v", boxes, want)
+	}
+}
+
+func TestRenderBannerLongWord(t *testing.T) {
+	// A word wider than the banner is drawn smaller to fit, keeping the
+	// letters' shape, instead of on a canvas sized to the full height
+	word := strings.Repeat("W", 64)
+	b := Banner{Message: word, Width: 10, Height: 32}
+	result, err := Render(b)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if cols := len([]rune(result.Rows[0])); cols > 10 {
+		t.Errorf("Long word is %d columns wide, want at most 10", cols)
+	}
+	if len(result.Rows) > 4 {
+		t.Errorf("Long word is %d rows tall; letters were squashed", len(result.Rows))
+	}
+	if bounds := result.Image.Bounds(); bounds.Dx() > 10*cellWidth+cellWidth {
+		t.Errorf("Image is %d pixels wide", bounds.Dx())
+	}
+
+	many := Banner{Message: strings.Repeat("W\n", 200), Width: 200, Height: 32, Options: BannerOptions{LineSpacing: 3}}
+	if _, err := Render(many); !errors.Is(err, ErrBannerTooLarge) {
+		t.Errorf("Render() of 200 lines error = %v, want ErrBannerTooLarge", err)
+	}
+}
+
+func TestRenderBannerStyledOptions(t *testing.T) {
+	render := func(o BannerOptions) string {
+		t.Helper()
+		result, err := Render(Banner{Message: "HI", Width: 30, Height: 4, Options: o})
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		return result.String()
+	}
+
+	// A custom ramp holding the shadow characters gets other ones
+	art := render(BannerOptions{Characters: "#;, ", Style: StyleShadow})
+	if !strings.ContainsAny(art, "%o") {
+		t.Errorf("Shadow not drawn apart from a ramp using its characters:\n%s", art)
+	}
+
+	// Reversed banners shadow the letters, not the field: the shadow lies
+	// in the field, which fills the rows the shadow adds
+	art = render(BannerOptions{Reverse: true, Style: StyleShadow})
+	rows := strings.Split(strings.TrimSuffix(art, "\n"), "\n")
+	last := rows[len(rows)-1]
+	if !strings.Contains(last, ";") || !strings.Contains(last, "@") || strings.HasPrefix(last, " ") {
+		t.Errorf("Reversed shadow not drawn in the field:\n%s", art)
+	}
+}
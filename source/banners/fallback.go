@@ -0,0 +1,58 @@
+package banners
+
+import (
+	"image"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// fallbackFace draws each rune with the first of its faces that has a
+// glyph for it. Metrics come from the first face, so lines are laid out by
+// the primary font. Runes no face has are drawn by the first face, usually
+// as its missing-glyph box.
+type fallbackFace []font.Face
+
+func (f fallbackFace) face(r rune) font.Face {
+	for _, face := range f {
+		if _, ok := face.GlyphAdvance(r); ok {
+			return face
+		}
+	}
+	return f[0]
+}
+
+func (f fallbackFace) Close() error {
+	var first error
+	for _, face := range f {
+		if err := face.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (f fallbackFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	return f.face(r).Glyph(dot, r)
+}
+
+func (f fallbackFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	return f.face(r).GlyphBounds(r)
+}
+
+func (f fallbackFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	return f.face(r).GlyphAdvance(r)
+}
+
+// Kern applies kerning only between runes drawn by the same face
+func (f fallbackFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	face := f.face(r0)
+	if face != f.face(r1) {
+		return 0
+	}
+	return face.Kern(r0, r1)
+}
+
+func (f fallbackFace) Metrics() font.Metrics {
+	return f[0].Metrics()
+}
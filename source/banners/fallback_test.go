@@ -0,0 +1,86 @@
+package banners
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFallbackFace(t *testing.T) {
+	primary, err := DefaultFonts().Face("Notable-Regular", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fallback, err := DefaultFonts().Face("SourceCodePro-Regular", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	face := fallbackFace{primary, fallback}
+	defer face.Close()
+
+	if face.face('A') != primary || face.face('Ж') != fallback {
+		t.Error("Expected A from the primary font and Ж from the fallback")
+	}
+	// Neither font has CJK, so the primary font's missing glyph would be
+	// drawn; MissingRunes reports such text before it is rendered
+	if face.face('漢') != primary {
+		t.Error("Expected runes no font has to use the primary font")
+	}
+	if _, ok := face.GlyphAdvance('Ж'); !ok {
+		t.Error("GlyphAdvance('Ж') not ok")
+	}
+	if k := face.Kern('A', 'Ж'); k != 0 {
+		t.Errorf("Kern across faces = %v, want 0", k)
+	}
+	if face.Metrics() != primary.Metrics() {
+		t.Error("Expected the primary font's metrics")
+	}
+}
+
+func TestRenderBannerFallback(t *testing.T) {
+	render := func(fallback []Font) string {
+		t.Helper()
+		b := Banner{Message: "ЖЖ", Width: 40, Height: 4, Options: BannerOptions{Fallback: fallback}}
+		result, err := Render(b)
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		return result.String()
+	}
+	without, with := render(nil), render([]Font{"SourceCodePro-Regular"})
+	if with == without || strings.TrimSpace(with) == "" {
+		t.Errorf("Fallback font not used:\n%s", with)
+	}
+
+	b := Banner{Message: "Hi", Path: filepath.Join(t.TempDir(), "banner"), Options: BannerOptions{Fallback: []Font{"Missing"}}}
+	if _, err := Render(b); err == nil {
+		t.Error("Expected error for an unknown fallback font")
+	}
+}
+
+func TestMissingRunes(t *testing.T) {
+	tests := []struct {
+		font     Font
+		fallback []Font
+		message  string
+		want     []rune
+	}{
+		{"Notable-Regular", nil, "Hi there", nil},
+		{"Notable-Regular", nil, "Жж Ж", []rune("Жж")},
+		{"Notable-Regular", []Font{"SourceCodePro-Regular"}, "Hi Жж\nλ", nil},
+		{"Notable-Regular", []Font{"SourceCodePro-Regular"}, "Hi 漢字", []rune("漢字")},
+		{"standard", []Font{"SourceCodePro-Regular"}, "Hi Ж", []rune("Ж")},
+	}
+	for _, tt := range tests {
+		b := Banner{Message: tt.message, Options: BannerOptions{Font: tt.font, Fallback: tt.fallback}}
+		got, err := b.MissingRunes()
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("MissingRunes(%q in %s) = %q, %v, want %q", tt.message, tt.font, got, err, tt.want)
+		}
+	}
+	b := Banner{Message: "Ж", Options: BannerOptions{Fallback: []Font{"Missing"}}}
+	if _, err := b.MissingRunes(); err == nil {
+		t.Error("Expected error for an unknown fallback font")
+	}
+}
@@ -0,0 +1,452 @@
+package banners
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// LayoutMode selects how FIGlet characters are joined horizontally
+type LayoutMode int
+
+const (
+	LayoutDefault   LayoutMode = iota // the font's own layout
+	LayoutFullWidth                   // each character at its full width
+	LayoutFitting                     // characters moved together until they touch
+	LayoutSmushing                    // characters overlapping by one column where the rules allow
+)
+
+func (m LayoutMode) String() string {
+	switch m {
+	case LayoutDefault:
+		return "default"
+	case LayoutFullWidth:
+		return "full"
+	case LayoutFitting:
+		return "fit"
+	case LayoutSmushing:
+		return "smush"
+	default:
+		return fmt.Sprintf("LayoutMode(%d)", int(m))
+	}
+}
+
+// ParseLayoutMode returns the mode named by String
+func ParseLayoutMode(name string) (LayoutMode, error) {
+	for m := LayoutDefault; m <= LayoutSmushing; m++ {
+		if m.String() == name {
+			return m, nil
+		}
+	}
+	return LayoutDefault, fmt.Errorf("unknown layout mode: %q", name)
+}
+
+// SmushRule is a set of FIGlet horizontal smushing rules. With no rules,
+// smushing is universal: the later character wins any overlap.
+type SmushRule int
+
+const (
+	SmushEqual        SmushRule = 1 << iota // identical characters merge
+	SmushUnderscore                         // _ is replaced by |/\[]{}()<>
+	SmushHierarchy                          // the later class of | /\ [] {} () <> wins
+	SmushOppositePair                       // [] {} () and their mirrors become |
+	SmushBigX                               // /\ becomes |, \/ becomes Y, >< becomes X
+	SmushHardblank                          // two hardblanks merge
+)
+
+// Full_Layout bits from the font header
+const (
+	layoutRuleMask   = 63
+	layoutHorizFit   = 64
+	layoutHorizSmush = 128
+)
+
+// The characters every FIGlet font defines after ASCII 32-126
+var germanChars = []rune{196, 214, 220, 228, 246, 252, 223}
+
+// FIGletFont is a parsed FIGlet (.flf) font
+type FIGletFont struct {
+	Hardblank      rune
+	Height         int
+	Baseline       int
+	MaxLength      int
+	PrintDirection int // 0 left to right, 1 right to left
+	Layout         LayoutMode
+	Rules          SmushRule
+	Comment        string
+
+	chars map[rune][]string
+}
+
+// ParseFIGlet reads a FIGlet font in the flf2a format: the header, comment
+// lines, the required ASCII and German characters and any code-tagged
+// characters that follow
+func ParseFIGlet(r io.Reader) (*FIGletFont, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 4096), 1<<20)
+	line := 0
+	next := func() (string, bool) {
+		if !sc.Scan() {
+			return "", false
+		}
+		line++
+		return strings.TrimRight(sc.Text(), "\r"), true
+	}
+
+	header, ok := next()
+	if !ok {
+		return nil, errors.New("figlet: empty font")
+	}
+	f, comments, err := parseFIGletHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	var comment []string
+	for i := 0; i < comments; i++ {
+		s, ok := next()
+		if !ok {
+			return nil, errors.New("figlet: truncated comment")
+		}
+		comment = append(comment, s)
+	}
+	f.Comment = strings.Join(comment, "\n")
+
+	readChar := func() ([]string, error) {
+		rows := make([]string, f.Height)
+		for i := range rows {
+			s, ok := next()
+			if !ok {
+				return nil, io.ErrUnexpectedEOF
+			}
+			rows[i] = trimEndmarks(s)
+		}
+		return rows, nil
+	}
+
+	f.chars = make(map[rune][]string)
+	for c := rune(32); c <= 126; c++ {
+		rows, err := readChar()
+		if err != nil {
+			return nil, fmt.Errorf("figlet: character %q: %w", c, err)
+		}
+		f.chars[c] = rows
+	}
+	// Some older fonts stop before the German characters
+	for _, c := range germanChars {
+		rows, err := readChar()
+		if err == io.ErrUnexpectedEOF {
+			return f, sc.Err()
+		}
+		f.chars[c] = rows
+	}
+	for {
+		tag, ok := next()
+		if !ok {
+			break
+		}
+		if strings.TrimSpace(tag) == "" {
+			continue
+		}
+		code, err := parseCodeTag(tag)
+		if err != nil {
+			return nil, fmt.Errorf("figlet: line %d: %w", line, err)
+		}
+		rows, err := readChar()
+		if err != nil {
+			return nil, fmt.Errorf("figlet: character %d: %w", code, err)
+		}
+		// Negative codes are reserved for translation tables
+		if code >= 0 {
+			f.chars[code] = rows
+		}
+	}
+	return f, sc.Err()
+}
+
+func parseFIGletHeader(header string) (*FIGletFont, int, error) {
+	fields := strings.Fields(header)
+	if len(fields) < 6 || !strings.HasPrefix(fields[0], "flf2a") || len([]rune(fields[0])) != 6 {
+		return nil, 0, errors.New("figlet: not a flf2a font")
+	}
+	var nums [8]int
+	nums[6] = -1 // full layout absent
+	for i, s := range fields[1:] {
+		if i >= len(nums) {
+			break
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, 0, fmt.Errorf("figlet: invalid header field %q", s)
+		}
+		nums[i] = n
+	}
+	height, baseline, maxLength, oldLayout, comments := nums[0], nums[1], nums[2], nums[3], nums[4]
+	if height < 1 || comments < 0 {
+		return nil, 0, errors.New("figlet: invalid header")
+	}
+	f := &FIGletFont{
+		Hardblank:      []rune(fields[0])[5],
+		Height:         height,
+		Baseline:       baseline,
+		MaxLength:      maxLength,
+		PrintDirection: nums[5],
+	}
+
+	full := nums[6]
+	if full < 0 {
+		// Derive the full layout from the old one
+		switch {
+		case oldLayout < 0:
+			full = 0
+		case oldLayout == 0:
+			full = layoutHorizFit
+		default:
+			full = oldLayout&layoutRuleMask | layoutHorizSmush
+		}
+	}
+	switch {
+	case full&layoutHorizSmush != 0:
+		f.Layout = LayoutSmushing
+	case full&layoutHorizFit != 0:
+		f.Layout = LayoutFitting
+	default:
+		f.Layout = LayoutFullWidth
+	}
+	f.Rules = SmushRule(full & layoutRuleMask)
+	return f, comments, nil
+}
+
+// trimEndmarks removes trailing whitespace and the endmark characters that
+// close each line of a character
+func trimEndmarks(s string) string {
+	s = strings.TrimRight(s, " \t")
+	if s == "" {
+		return s
+	}
+	end, _ := utf8.DecodeLastRuneInString(s)
+	return strings.TrimRight(s, string(end))
+}
+
+// parseCodeTag reads the character code at the start of a code tag line, in
+// decimal, 0x hexadecimal or 0 octal
+func parseCodeTag(tag string) (rune, error) {
+	field, _, _ := strings.Cut(strings.TrimSpace(tag), " ")
+	n, err := strconv.ParseInt(field, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid code tag %q", field)
+	}
+	return rune(n), nil
+}
+
+// Has reports whether the font defines c
+func (f *FIGletFont) Has(c rune) bool {
+	_, ok := f.chars[c]
+	return ok
+}
+
+// Render lays out one line of text, returning Height rows with hardblanks
+// replaced by spaces. Characters the font lacks are drawn with its
+// character 0 if it has one and skipped otherwise.
+func (f *FIGletFont) Render(text string, mode LayoutMode) []string {
+	lines, _ := f.render(text, mode)
+	return lines
+}
+
+// render is Render that also returns, for each cell, the character of text
+// that drew it
+func (f *FIGletFont) render(text string, mode LayoutMode) ([]string, [][]rune) {
+	if mode == LayoutDefault {
+		mode = f.Layout
+	}
+	out := make([][]rune, f.Height)
+	owners := make([][]rune, f.Height)
+	prevWidth := 0
+	runes := []rune(text)
+	if f.PrintDirection == 1 {
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+	}
+	for _, c := range runes {
+		rows, ok := f.chars[c]
+		if !ok {
+			if rows, ok = f.chars[0]; !ok {
+				continue
+			}
+		}
+		glyph := make([][]rune, f.Height)
+		width := 0
+		for i, row := range rows {
+			glyph[i] = []rune(row)
+			width = max(width, len(glyph[i]))
+		}
+		s := &smusher{font: f, mode: mode, prevWidth: prevWidth, width: width}
+		overlap := 0
+		if mode != LayoutFullWidth {
+			overlap = s.amount(out, glyph)
+		}
+		for i := range out {
+			out[i], owners[i] = s.add(out[i], owners[i], glyph[i], c, overlap)
+		}
+		prevWidth = width
+	}
+
+	lines := make([]string, f.Height)
+	for i, row := range out {
+		lines[i] = strings.ReplaceAll(string(row), string(f.Hardblank), " ")
+	}
+	return lines, owners
+}
+
+// smusher joins one character onto the output, following figlet's
+// smushamt and smushem
+type smusher struct {
+	font      *FIGletFont
+	mode      LayoutMode
+	prevWidth int
+	width     int
+}
+
+// amount returns how many columns the character can overlap the output.
+// Leading blank columns of the first character are absorbed too.
+func (s *smusher) amount(out, glyph [][]rune) int {
+	amount := s.width
+	for i := range out {
+		line, row := out[i], glyph[i]
+		lineEnd := len(line)
+		var l rune
+		for {
+			l = 0
+			if lineEnd < len(line) {
+				l = line[lineEnd]
+			}
+			if lineEnd > 0 && (l == 0 || l == ' ') {
+				lineEnd--
+			} else {
+				break
+			}
+		}
+		rowStart := 0
+		for rowStart < len(row) && row[rowStart] == ' ' {
+			rowStart++
+		}
+		n := rowStart + len(line) - 1 - lineEnd
+		switch {
+		case l == 0 || l == ' ':
+			n++
+		case rowStart < len(row):
+			if _, ok := s.smush(l, row[rowStart]); ok {
+				n++
+			}
+		}
+		amount = min(amount, n)
+	}
+	return max(amount, 0)
+}
+
+// add appends row, drawn by c, to line, overlapping the last overlap
+// columns. owners records which character drew each column of line.
+func (s *smusher) add(line, owners, row []rune, c rune, overlap int) ([]rune, []rune) {
+	for len(row) < s.width {
+		row = append(row, ' ')
+	}
+	overlap = min(overlap, len(row))
+	for i := 0; i < overlap; i++ {
+		// Columns before the start of the line are blank and dropped
+		if col := len(line) - overlap + i; col >= 0 {
+			if sc, ok := s.smush(line[col], row[i]); ok {
+				if sc != line[col] {
+					owners[col] = c
+				}
+				line[col] = sc
+			}
+		}
+	}
+	for range row[overlap:] {
+		owners = append(owners, c)
+	}
+	return append(line, row[overlap:]...), owners
+}
+
+// smush returns the character that replaces l and r when they overlap
+func (s *smusher) smush(l, r rune) (rune, bool) {
+	if l == ' ' {
+		return r, true
+	}
+	if r == ' ' {
+		return l, true
+	}
+	if s.mode != LayoutSmushing || s.prevWidth < 2 || s.width < 2 {
+		return 0, false
+	}
+	hb := s.font.Hardblank
+	rules := s.font.Rules
+	if rules == 0 {
+		// Universal smushing: the later character wins, except over
+		// hardblanks
+		switch {
+		case l == hb:
+			return r, true
+		case r == hb:
+			return l, true
+		}
+		return r, true
+	}
+	if l == hb || r == hb {
+		if l == hb && r == hb && rules&SmushHardblank != 0 {
+			return l, true
+		}
+		return 0, false
+	}
+	if rules&SmushEqual != 0 && l == r {
+		return l, true
+	}
+	if rules&SmushUnderscore != 0 {
+		const replacers = `|/\[]{}()<>`
+		if l == '_' && strings.ContainsRune(replacers, r) {
+			return r, true
+		}
+		if r == '_' && strings.ContainsRune(replacers, l) {
+			return l, true
+		}
+	}
+	if rules&SmushHierarchy != 0 {
+		classes := []string{"|", `/\`, "[]", "{}", "()", "<>"}
+		lc, rc := -1, -1
+		for i, class := range classes {
+			if strings.ContainsRune(class, l) {
+				lc = i
+			}
+			if strings.ContainsRune(class, r) {
+				rc = i
+			}
+		}
+		if lc >= 0 && rc >= 0 && lc != rc {
+			if rc > lc {
+				return r, true
+			}
+			return l, true
+		}
+	}
+	if rules&SmushOppositePair != 0 {
+		switch string([]rune{l, r}) {
+		case "[]", "][", "{}", "}{", "()", ")(":
+			return '|', true
+		}
+	}
+	if rules&SmushBigX != 0 {
+		switch string([]rune{l, r}) {
+		case `/\`:
+			return '|', true
+		case `\/`:
+			return 'Y', true
+		case "><":
+			return 'X', true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,182 @@
+package banners
+
+import (
+	"strings"
+	"testing"
+)
+
+// testFont builds a two-row font whose characters are all "?" except the
+// ones given, in the order of the required characters
+func testFont(header string, chars map[rune][2]string, extra string) string {
+	var sb strings.Builder
+	sb.WriteString(header + "\n")
+	for c := rune(32); c <= 126; c++ {
+		rows, ok := chars[c]
+		if !ok {
+			rows = [2]string{"?", "?"}
+		}
+		sb.WriteString(rows[0] + "@\n" + rows[1] + "@@\n")
+	}
+	sb.WriteString(extra)
+	return sb.String()
+}
+
+func TestParseFIGletHeader(t *testing.T) {
+	tests := []struct {
+		header string
+		layout LayoutMode
+		rules  SmushRule
+	}{
+		{"flf2a$ 2 1 4 -1 0", LayoutFullWidth, 0},
+		{"flf2a$ 2 1 4 0 0", LayoutFitting, 0},
+		{"flf2a$ 2 1 4 5 0", LayoutSmushing, SmushEqual | SmushHierarchy},
+		{"flf2a$ 2 1 4 0 0 0 129", LayoutSmushing, SmushEqual},
+		{"flf2a$ 2 1 4 15 0 0 64", LayoutFitting, 0},
+	}
+	for _, tt := range tests {
+		f, err := ParseFIGlet(strings.NewReader(testFont(tt.header, nil, "")))
+		if err != nil {
+			t.Fatalf("%s: ParseFIGlet() error = %v", tt.header, err)
+		}
+		if f.Layout != tt.layout || f.Rules != tt.rules || f.Hardblank != '$' || f.Height != 2 {
+			t.Errorf("%s: layout %v rules %d", tt.header, f.Layout, f.Rules)
+		}
+	}
+
+	for _, bad := range []string{"", "flf2 2 1 4 0 0", "flf2a$ x 1 4 0 0", "flf2a$ 0 1 4 0 0", "flf2a$ 2 1"} {
+		if _, err := ParseFIGlet(strings.NewReader(testFont(bad, nil, ""))); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestParseFIGletCharacters(t *testing.T) {
+	extra := "" +
+		"a@\nb@@\n" + // Ä, then the other German characters
+		strings.Repeat("@\n@@\n", 6) +
+		"0x263A  WHITE SMILING FACE\n:)@\n  @@\n" +
+		"0101 octal A\nA8#\n8##\n" +
+		"-2 translation entry\nx@\nx@@\n"
+	src := "flf2a$ 2 1 4 0 1\ncomment line\n" + testFont("", map[rune][2]string{'H': {"H  H", "H$$H"}}, extra)[1:]
+	f, err := ParseFIGlet(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ParseFIGlet() error = %v", err)
+	}
+	if f.Comment != "comment line" {
+		t.Errorf("Comment = %q", f.Comment)
+	}
+	tests := []struct {
+		c    rune
+		rows []string
+	}{
+		{'H', []string{"H  H", "H$$H"}},
+		{'Ä', []string{"a", "b"}},
+		{'☺', []string{":)", "  "}},
+		{'A', []string{"A8", "8"}}, // the code-tagged 0101 replaces A
+	}
+	for _, tt := range tests {
+		if got := f.chars[tt.c]; strings.Join(got, "|") != strings.Join(tt.rows, "|") {
+			t.Errorf("%q = %q, want %q", tt.c, got, tt.rows)
+		}
+	}
+	if f.Has(-2) || !f.Has('ß') {
+		t.Error("Unexpected character set")
+	}
+
+	old, err := ParseFIGlet(strings.NewReader(testFont("flf2a$ 2 1 4 0 0", nil, "")))
+	if err != nil || old.Has('Ä') {
+		t.Errorf("Font without German characters: %v", err)
+	}
+
+	truncated := "flf2a$ 2 1 4 0 0\n@\n@@\n"
+	if _, err := ParseFIGlet(strings.NewReader(truncated)); err == nil {
+		t.Error("Expected error for a font missing required characters")
+	}
+}
+
+func TestFIGletRender(t *testing.T) {
+	f, err := DefaultFonts().FIGlet("standard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		mode     LayoutMode
+		expected []string
+	}{
+		{LayoutFullWidth, []string{
+			"  _   _   _ ",
+			" | | | | (_)",
+			" | |_| | | |",
+			" |  _  | | |",
+			" |_| |_| |_|",
+			"            ",
+		}},
+		{LayoutFitting, []string{
+			" _   _  _ ",
+			"| | | |(_)",
+			"| |_| || |",
+			"|  _  || |",
+			"|_| |_||_|",
+			"          ",
+		}},
+		{LayoutDefault, []string{
+			" _   _ _ ",
+			"| | | (_)",
+			"| |_| | |",
+			"|  _  | |",
+			"|_| |_|_|",
+			"         ",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			got := f.Render("Hi", tt.mode)
+			if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("Render() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.expected, "\n"))
+			}
+		})
+	}
+}
+
+func TestSmushRules(t *testing.T) {
+	tests := []struct {
+		rules SmushRule
+		l, r  rune
+		want  rune
+		ok    bool
+	}{
+		{0, 'a', 'b', 'b', true},
+		{0, '$', 'b', 'b', true},
+		{SmushEqual, '|', '|', '|', true},
+		{SmushEqual, '|', '/', 0, false},
+		{SmushUnderscore, '_', '/', '/', true},
+		{SmushUnderscore, '[', '_', '[', true},
+		{SmushHierarchy, '|', '/', '/', true},
+		{SmushHierarchy, '}', '(', '(', true},
+		{SmushHierarchy, '<', '/', '<', true},
+		{SmushOppositePair, ']', '[', '|', true},
+		{SmushBigX, '/', '\\', '|', true},
+		{SmushBigX, '\\', '/', 'Y', true},
+		{SmushBigX, '>', '<', 'X', true},
+		{SmushHardblank, '$', '$', '$', true},
+		{SmushEqual, '$', '$', 0, false},
+	}
+	for _, tt := range tests {
+		s := &smusher{font: &FIGletFont{Hardblank: '$', Rules: tt.rules}, mode: LayoutSmushing, prevWidth: 2, width: 2}
+		got, ok := s.smush(tt.l, tt.r)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("rules %d: smush(%q, %q) = %q, %v; want %q, %v", tt.rules, tt.l, tt.r, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseLayoutMode(t *testing.T) {
+	for m := LayoutDefault; m <= LayoutSmushing; m++ {
+		if got, err := ParseLayoutMode(m.String()); err != nil || got != m {
+			t.Errorf("ParseLayoutMode(%q) = %v, %v", m.String(), got, err)
+		}
+	}
+	if _, err := ParseLayoutMode("wide"); err == nil {
+		t.Error("Expected error for unknown layout mode")
+	}
+}
@@ -0,0 +1,176 @@
+package banners
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/MhunterDev/img2ascii/source/img2ascii"
+	"github.com/fogleman/gg"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+)
+
+const defaultFIGletHeight = 8
+
+// FIGletOptions controls the conversion of a TrueType font to FIGlet
+type FIGletOptions struct {
+	Height     int    // rows per character; 0 means 8
+	Characters string // ramp, darkest first; empty means the banner ramp
+	Extra      string // characters beyond ASCII and German to add as code-tagged characters
+}
+
+// WriteFIGlet rasterizes each printable ASCII character, the German
+// characters FIGlet requires and opts.Extra from the named TrueType font,
+// converts them with the banner ramp and writes the result to w as a FIGlet
+// (.flf) font. Characters the font lacks are written empty. The font's
+// copyright and licence are copied into the comment.
+func (r *FontRegistry) WriteFIGlet(w io.Writer, name Font, opts FIGletOptions) error {
+	f, err := r.Lookup(name)
+	if err != nil {
+		return err
+	}
+	height := opts.Height
+	if height <= 0 {
+		height = defaultFIGletHeight
+	}
+
+	face, err := r.FaceForHeight(name, float64(height*cellHeight))
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+	ascent := float64(face.Metrics().Ascent) / 64
+
+	chars := make([]rune, 0, 95+len(germanChars))
+	for c := rune(32); c <= 126; c++ {
+		chars = append(chars, c)
+	}
+	chars = append(chars, germanChars...)
+	var extra []rune
+	for _, c := range opts.Extra {
+		if (c < 32 || c > 126) && !containsRune(germanChars, c) && !containsRune(extra, c) {
+			extra = append(extra, c)
+		}
+	}
+
+	ramp := opts.Characters
+	if ramp == "" {
+		ramp = img2ascii.BannerCharacters
+	}
+	hardblank := pickUnused(ramp, "$^~`'")
+	endmark := pickUnused(ramp+string(hardblank), "@#|!%&")
+
+	glyphs := make(map[rune][]string, len(chars)+len(extra))
+	maxLength := 0
+	for _, c := range append(chars, extra...) {
+		rows, err := rasterizeGlyph(face, c, height, ascent, ramp, hardblank)
+		if err != nil {
+			return fmt.Errorf("character %q: %w", c, err)
+		}
+		glyphs[c] = rows
+		for _, row := range rows {
+			maxLength = max(maxLength, len([]rune(row))+2)
+		}
+	}
+
+	var buf sfnt.Buffer
+	comment := []string{fmt.Sprintf("Converted from %s by img2ascii at %d rows", name, height)}
+	for _, id := range []sfnt.NameID{sfnt.NameIDCopyright, sfnt.NameIDLicense, sfnt.NameIDLicenseURL} {
+		if s, err := f.Name(&buf, id); err == nil && s != "" {
+			comment = append(comment, strings.Split(strings.ReplaceAll(s, "\r", ""), "\n")...)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	// Horizontal fitting (Full_Layout 64): rasterized glyphs have no
+	// smushing rules
+	baseline := max(1, min(height, int(math.Round(ascent/cellHeight))))
+	fmt.Fprintf(bw, "flf2a%c %d %d %d 0 %d 0 64 %d\n", hardblank, height, baseline, maxLength, len(comment), len(extra))
+	for _, line := range comment {
+		bw.WriteString(line + "\n")
+	}
+	writeChar := func(rows []string) {
+		for i, row := range rows {
+			bw.WriteString(row)
+			bw.WriteRune(endmark)
+			if i == len(rows)-1 {
+				bw.WriteRune(endmark)
+			}
+			bw.WriteByte('\n')
+		}
+	}
+	for _, c := range chars {
+		writeChar(glyphs[c])
+	}
+	for _, c := range extra {
+		fmt.Fprintf(bw, "0x%04X  U+%04X\n", c, c)
+		writeChar(glyphs[c])
+	}
+	return bw.Flush()
+}
+
+// rasterizeGlyph draws c with the gg pipeline and converts it to height rows
+// of characters. Its advance plus one column of spacing sets the width, and
+// the space character is made of hardblanks so layout cannot remove it.
+func rasterizeGlyph(face font.Face, c rune, height int, ascent float64, ramp string, hardblank rune) ([]string, error) {
+	advance, ok := face.GlyphAdvance(c)
+	if !ok {
+		return make([]string, height), nil
+	}
+	bounds, _, _ := face.GlyphBounds(c)
+	// Cover ink that overhangs the advance, as in italic and script fonts
+	left := min(0, float64(bounds.Min.X)/64)
+	right := max(float64(advance)/64, float64(bounds.Max.X)/64)
+	cols := max(1, int(math.Ceil((right-left)/cellWidth)))
+	if c == ' ' {
+		row := strings.Repeat(string(hardblank), cols)
+		rows := make([]string, height)
+		for i := range rows {
+			rows[i] = row
+		}
+		return rows, nil
+	}
+
+	dc := gg.NewContext(cols*cellWidth, height*cellHeight)
+	dc.SetRGB(1, 1, 1)
+	dc.Clear()
+	dc.SetFontFace(face)
+	dc.SetRGB(0, 0, 0)
+	dc.DrawString(string(c), -left, ascent)
+
+	art, err := img2ascii.ConvertImage(dc.Image(), img2ascii.ConversionOptions{
+		Mode:       img2ascii.ModeBanner,
+		Characters: ramp,
+		Layout:     img2ascii.Layout{Fit: img2ascii.FitStretch, Width: cols, Height: height},
+	})
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]string, height)
+	for i, row := range art.Rows {
+		rows[i] = row + " "
+	}
+	return rows, nil
+}
+
+// pickUnused returns the first of candidates not in used
+func pickUnused(used, candidates string) rune {
+	for _, c := range candidates {
+		if !strings.ContainsRune(used, c) {
+			return c
+		}
+	}
+	return 0x7f
+}
+
+func containsRune(rs []rune, c rune) bool {
+	for _, r := range rs {
+		if r == c {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package banners
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteFIGlet(t *testing.T) {
+	var buf bytes.Buffer
+	err := DefaultFonts().WriteFIGlet(&buf, "SourceCodePro-Regular", FIGletOptions{Height: 6, Extra: "é"})
+	if err != nil {
+		t.Fatalf("WriteFIGlet() error = %v", err)
+	}
+	f, err := ParseFIGlet(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ParseFIGlet() error = %v\n%s", err, buf.String())
+	}
+	if f.Height != 6 || f.Layout != LayoutFitting {
+		t.Errorf("font = %+v", f)
+	}
+	if !f.Has('é') || !f.Has('Ü') || !f.Has('~') {
+		t.Error("Expected ASCII, German and extra characters")
+	}
+	if !strings.Contains(f.Comment, "SourceCodePro-Regular") {
+		t.Errorf("Comment = %q", f.Comment)
+	}
+
+	lines := f.Render("I I", LayoutDefault)
+	if len(lines) != 6 {
+		t.Fatalf("Render() returned %d lines", len(lines))
+	}
+	ink := 0
+	for _, line := range lines {
+		ink += len(strings.TrimSpace(line))
+	}
+	if ink == 0 {
+		t.Errorf("Render() has no ink:\n%s", strings.Join(lines, "\n"))
+	}
+	// The space keeps the two letters apart
+	for _, line := range lines {
+		if strings.Contains(line, "$") {
+			t.Errorf("Hardblank left in %q", line)
+		}
+	}
+
+	if err := DefaultFonts().WriteFIGlet(&buf, "standard", FIGletOptions{}); err == nil {
+		t.Error("Expected error converting a FIGlet font")
+	}
+}
@@ -0,0 +1,319 @@
+package banners
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+)
+
+//go:embed fonts/*.ttf fonts/*.flf
+var embeddedFonts embed.FS
+
+// DefaultFont is used when BannerOptions.Font is empty
+const DefaultFont = Font("Notable-Regular")
+
+// ErrUnknownFont is returned for font names that are not registered
+var ErrUnknownFont = errors.New("unknown font")
+
+// Font kinds reported in FontInfo
+const (
+	KindTrueType = "truetype"
+	KindFIGlet   = "figlet"
+)
+
+// FontInfo describes a registered font
+type FontInfo struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"` // KindTrueType or KindFIGlet
+	Family string `json:"family"`
+	Style  string `json:"style"`
+	Glyphs int    `json:"glyphs"`
+	Source string `json:"source"`         // "embedded" or the directory the font was loaded from
+	Axes   []Axis `json:"axes,omitempty"` // the design axes of a variable font
+}
+
+type fontEntry struct {
+	info   FontInfo
+	font   *opentype.Font
+	vf     *varFont // nil for fonts without axes
+	figlet *FIGletFont
+}
+
+// FontRegistry holds parsed TrueType, OpenType and FIGlet fonts by name.
+// Fonts are parsed once when added and shared; Face creates a new face per
+// call because faces are not safe for concurrent use.
+type FontRegistry struct {
+	mu    sync.RWMutex
+	fonts map[Font]*fontEntry
+}
+
+// NewFontRegistry returns a registry holding the fonts embedded in the
+// binary, named after their files without the extension
+func NewFontRegistry() *FontRegistry {
+	r := &FontRegistry{fonts: make(map[Font]*fontEntry)}
+	names, err := embeddedFonts.ReadDir("fonts")
+	if err != nil {
+		panic("banners: reading embedded fonts: " + err.Error())
+	}
+	for _, e := range names {
+		data, err := embeddedFonts.ReadFile(path.Join("fonts", e.Name()))
+		if err == nil {
+			err = r.addFile(e.Name(), data, "embedded")
+		}
+		if err != nil {
+			panic("banners: loading embedded font: " + err.Error())
+		}
+	}
+	return r
+}
+
+var defaultFonts = sync.OnceValue(NewFontRegistry)
+
+// DefaultFonts returns the shared registry of embedded fonts
+func DefaultFonts() *FontRegistry {
+	return defaultFonts()
+}
+
+func fontName(file string) Font {
+	return Font(strings.TrimSuffix(file, filepath.Ext(file)))
+}
+
+// Add parses a TrueType or OpenType font and registers it under name
+func (r *FontRegistry) Add(name Font, data []byte, source string) error {
+	f, err := opentype.Parse(data)
+	if err != nil {
+		return fmt.Errorf("font %s: %w", name, err)
+	}
+	// Variation data this package cannot read leaves the font static
+	vf, err := parseVarFont(data, f)
+	if err != nil {
+		slog.Warn("ignoring font variations", "font", string(name), "err", err)
+		vf = nil
+	}
+	var buf sfnt.Buffer
+	family, _ := f.Name(&buf, sfnt.NameIDFamily)
+	style, _ := f.Name(&buf, sfnt.NameIDSubfamily)
+	info := FontInfo{Name: string(name), Kind: KindTrueType, Family: family, Style: style, Glyphs: f.NumGlyphs(), Source: source}
+	if vf != nil {
+		info.Axes = vf.axes
+	}
+	return r.add(name, &fontEntry{info: info, font: f, vf: vf})
+}
+
+// AddFIGlet parses a FIGlet (.flf) font and registers it under name
+func (r *FontRegistry) AddFIGlet(name Font, data []byte, source string) error {
+	f, err := ParseFIGlet(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("font %s: %w", name, err)
+	}
+	return r.add(name, &fontEntry{
+		info:   FontInfo{Name: string(name), Kind: KindFIGlet, Family: string(name), Glyphs: len(f.chars), Source: source},
+		figlet: f,
+	})
+}
+
+func (r *FontRegistry) add(name Font, e *fontEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.fonts[name]; dup {
+		return fmt.Errorf("font %s already registered", name)
+	}
+	r.fonts[name] = e
+	return nil
+}
+
+// addFile registers a font file by its extension
+func (r *FontRegistry) addFile(file string, data []byte, source string) error {
+	name := fontName(file)
+	if strings.EqualFold(filepath.Ext(file), ".flf") {
+		return r.AddFIGlet(name, data, source)
+	}
+	return r.Add(name, data, source)
+}
+
+// LoadDir adds every .ttf, .otf and .flf file in dir, returning the names
+// added
+func (r *FontRegistry) LoadDir(dir string) ([]Font, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var added []Font
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || ext != ".ttf" && ext != ".otf" && ext != ".flf" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return added, err
+		}
+		if err := r.addFile(e.Name(), data, dir); err != nil {
+			return added, err
+		}
+		added = append(added, fontName(e.Name()))
+	}
+	return added, nil
+}
+
+// Fonts returns the registered fonts sorted by name
+func (r *FontRegistry) Fonts() []FontInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	infos := make([]FontInfo, 0, len(r.fonts))
+	for _, e := range r.fonts {
+		infos = append(infos, e.info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// Has reports whether name is registered
+func (r *FontRegistry) Has(name Font) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.fonts[name] != nil
+}
+
+func (r *FontRegistry) entry(name Font) (*fontEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.fonts[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFont, name)
+	}
+	return e, nil
+}
+
+// Lookup returns the parsed TrueType or OpenType font registered under name
+func (r *FontRegistry) Lookup(name Font) (*opentype.Font, error) {
+	e, err := r.entry(name)
+	if err != nil {
+		return nil, err
+	}
+	if e.font == nil {
+		return nil, fmt.Errorf("font %s is a %s font", name, e.info.Kind)
+	}
+	return e.font, nil
+}
+
+// FIGlet returns the FIGlet font registered under name
+func (r *FontRegistry) FIGlet(name Font) (*FIGletFont, error) {
+	e, err := r.entry(name)
+	if err != nil {
+		return nil, err
+	}
+	if e.figlet == nil {
+		return nil, fmt.Errorf("font %s is a %s font", name, e.info.Kind)
+	}
+	return e.figlet, nil
+}
+
+// HasGlyph reports whether the named font can draw c
+func (r *FontRegistry) HasGlyph(name Font, c rune) (bool, error) {
+	e, err := r.entry(name)
+	if err != nil {
+		return false, err
+	}
+	if e.figlet != nil {
+		return e.figlet.Has(c), nil
+	}
+	var buf sfnt.Buffer
+	x, err := e.font.GlyphIndex(&buf, c)
+	return err == nil && x != 0, nil
+}
+
+// Face returns a new face for the named font at size pixels
+func (r *FontRegistry) Face(name Font, size float64) (font.Face, error) {
+	f, err := r.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+}
+
+// FaceVariation returns a new face for the named font at size pixels,
+// drawing the instance of a variable font that v selects. An empty v gives
+// the default instance, as Face does.
+func (r *FontRegistry) FaceVariation(name Font, size float64, v Variation) (font.Face, error) {
+	e, coords, err := r.coords(name, v)
+	if err != nil {
+		return nil, err
+	}
+	base, err := r.Face(name, size)
+	if err != nil || coords == nil {
+		return base, err
+	}
+	return newVarFace(e.vf, e.font, base, size, coords), nil
+}
+
+// ValidateVariation reports whether v selects an instance of the named
+// font: every axis it names must exist and its value lie in the axis's
+// range. An empty v is valid for any font.
+func (r *FontRegistry) ValidateVariation(name Font, v Variation) error {
+	_, _, err := r.coords(name, v)
+	return err
+}
+
+// coords returns the normalized coordinates of v in the named font, or nil
+// for its default instance
+func (r *FontRegistry) coords(name Font, v Variation) (*fontEntry, []float64, error) {
+	e, err := r.entry(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(v) == 0 {
+		return e, nil, nil
+	}
+	if e.font == nil {
+		return nil, nil, fmt.Errorf("font %s is a %s font", name, e.info.Kind)
+	}
+	if e.vf == nil {
+		return nil, nil, fmt.Errorf("font %s is not a variable font", name)
+	}
+	coords, err := e.vf.normalize(v)
+	if err != nil {
+		return nil, nil, fmt.Errorf("font %s: %w", name, err)
+	}
+	for _, c := range coords {
+		if c != 0 {
+			return e, coords, nil
+		}
+	}
+	return e, nil, nil
+}
+
+// clampVariation returns the part of v the named font can draw: the axes
+// it has, limited to their ranges
+func (r *FontRegistry) clampVariation(name Font, v Variation) Variation {
+	e, err := r.entry(name)
+	if err != nil || e.vf == nil || len(v) == 0 {
+		return nil
+	}
+	return e.vf.clamp(v)
+}
+
+// FaceForHeight returns a new face for the named font sized so that its
+// ascent plus descent is height pixels
+func (r *FontRegistry) FaceForHeight(name Font, height float64) (font.Face, error) {
+	const probeSize = 100
+	probe, err := r.Face(name, probeSize)
+	if err != nil {
+		return nil, err
+	}
+	m := probe.Metrics()
+	probe.Close()
+	return r.Face(name, probeSize*height/(float64(m.Ascent+m.Descent)/64))
+}
@@ -0,0 +1,108 @@
+package banners
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultFonts(t *testing.T) {
+	fonts := DefaultFonts().Fonts()
+	want := []string{"Cookie-Regular", "Notable-Regular", "SourceCodePro-Italic-VariableFont_wght", "SourceCodePro-Regular", "standard"}
+	if len(fonts) != len(want) {
+		t.Fatalf("Fonts() returned %d fonts, want %d", len(fonts), len(want))
+	}
+	for i, f := range fonts {
+		if f.Name != want[i] || f.Source != "embedded" || f.Glyphs == 0 {
+			t.Errorf("Fonts()[%d] = %+v", i, f)
+		}
+	}
+	if fonts[3].Family != "Source Code Pro" || fonts[3].Style != "Regular" || fonts[3].Kind != KindTrueType {
+		t.Errorf("SourceCodePro-Regular = %+v", fonts[3])
+	}
+	if fonts[4].Kind != KindFIGlet || fonts[4].Glyphs != 102 {
+		t.Errorf("standard = %+v", fonts[4])
+	}
+
+	face, err := DefaultFonts().Face(DefaultFont, 24)
+	if err != nil {
+		t.Fatalf("Face() error = %v", err)
+	}
+	defer face.Close()
+	if adv, ok := face.GlyphAdvance('A'); !ok || adv <= 0 {
+		t.Errorf("GlyphAdvance('A') = %v, %v", adv, ok)
+	}
+
+	if _, err := DefaultFonts().Face("Missing", 24); !errors.Is(err, ErrUnknownFont) {
+		t.Errorf("Face(Missing) error = %v, want ErrUnknownFont", err)
+	}
+	if _, err := DefaultFonts().Face("standard", 24); err == nil {
+		t.Error("Expected error for a face of a FIGlet font")
+	}
+	if _, err := DefaultFonts().FIGlet(DefaultFont); err == nil {
+		t.Error("Expected error for FIGlet of a TrueType font")
+	}
+}
+
+func TestFontRegistryLoadDir(t *testing.T) {
+	data, err := embeddedFonts.ReadFile("fonts/Cookie-Regular.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	flf, err := embeddedFonts.ReadFile("fonts/standard.flf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for name, content := range map[string][]byte{
+		"Custom.ttf": data,
+		"mini.flf":   flf,
+		"notes.txt":  []byte("not a font"),
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewFontRegistry()
+	added, err := r.LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+	if len(added) != 2 || added[0] != "Custom" || added[1] != "mini" || !r.Has("mini") {
+		t.Errorf("LoadDir() = %v", added)
+	}
+	if _, err := r.LoadDir(dir); err == nil {
+		t.Error("Expected error loading a font name twice")
+	}
+	if err := r.Add("Broken", []byte("not a font"), dir); err == nil {
+		t.Error("Expected error adding an invalid font")
+	}
+}
+
+func TestRenderBannerFIGlet(t *testing.T) {
+	b := Banner{Message: "Hi", Path: filepath.Join(t.TempDir(), "banner"), Options: BannerOptions{Font: "standard"}}
+	if err := RenderBanner(b); err != nil {
+		t.Fatalf("RenderBanner() error = %v", err)
+	}
+	data, err := os.ReadFile(b.Path + ".txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := " _   _ _\n| | | (_)\n| |_| | |\n|  _  | |\n|_| |_|_|\n\n"
+	if string(data) != want {
+		t.Errorf("Banner = %q, want %q", data, want)
+	}
+}
+
+func TestRenderBannerOutsideRepo(t *testing.T) {
+	t.Chdir(t.TempDir()) // fonts must not depend on the working directory
+	b := Banner{Message: "Hi", Path: filepath.Join(t.TempDir(), "banner"), Width: 20, Height: 6}
+	if err := RenderBanner(b); err != nil {
+		t.Fatalf("RenderBanner() error = %v", err)
+	}
+	if info, err := os.Stat(b.Path + ".txt"); err != nil || info.Size() == 0 {
+		t.Errorf("Banner output missing: %v", err)
+	}
+}
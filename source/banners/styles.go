@@ -0,0 +1,287 @@
+package banners
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BannerStyle is an effect applied to the letters of a banner
+type BannerStyle int
+
+const (
+	StylePlain     BannerStyle = iota
+	StyleShadow                // a drop shadow below and to the right
+	StyleOutline               // hollow letters drawn by their edges
+	StyleExtrude               // letters extruded down and to the right in 3D
+	StyleUnderline             // a rule under each line of text
+)
+
+func (s BannerStyle) String() string {
+	switch s {
+	case StylePlain:
+		return "plain"
+	case StyleShadow:
+		return "shadow"
+	case StyleOutline:
+		return "outline"
+	case StyleExtrude:
+		return "3d"
+	case StyleUnderline:
+		return "underline"
+	default:
+		return fmt.Sprintf("BannerStyle(%d)", int(s))
+	}
+}
+
+// ParseBannerStyle returns the style named by String
+func ParseBannerStyle(name string) (BannerStyle, error) {
+	for s := StylePlain; s <= StyleUnderline; s++ {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+	return StylePlain, fmt.Errorf("unknown banner style: %q", name)
+}
+
+// Ramps for the cells each style adds, darkest first. They share no
+// characters with the default banner ramp so effects stand apart from
+// letters; effectChars swaps out any a custom ramp uses.
+const (
+	shadowRamp    = ";,"
+	outlineRamp   = "%o"
+	extrudeRamp   = "/'"
+	underlineRamp = "~"
+)
+
+// spareEffectChars replace effect characters that a custom ramp uses
+const spareEffectChars = ";,%o/'~^`\"_|<>!?+=-:."
+
+// effectChars returns effect with each character that is in ramp replaced
+// by a spare character in neither. If the ramp uses every spare, effect is
+// returned as it is.
+func effectChars(effect, ramp string) string {
+	out := []rune(effect)
+	used := ramp + effect
+	for i, c := range out {
+		if !strings.ContainsRune(ramp, c) {
+			continue
+		}
+		for _, spare := range spareEffectChars {
+			if !strings.ContainsRune(used, spare) {
+				out[i] = spare
+				used += string(spare)
+				break
+			}
+		}
+	}
+	return string(out)
+}
+
+// Offsets of a drop shadow and the steps of an extrusion in columns and
+// rows; cells are twice as tall as they are wide
+var (
+	shadowOffset   = [2]int{2, 1}
+	extrudeOffsets = [][2]int{{1, 1}, {2, 1}, {3, 2}, {4, 2}}
+)
+
+// applyStyle draws style onto rows of art, where ramp is the ramp the
+// letters were drawn with (darkest first) or empty for FIGlet fonts. Cells
+// a style adds take their character from the style's ramp at the density
+// of the letter cell they come from. selfFill reports that letters are drawn
+// in their own characters, which outlines keep.
+//
+// A custom ramp may end in a character other than a space, which then fills
+// the background. Such cells are styled as blanks and drawn in that
+// character again afterwards.
+func applyStyle(rows []string, style BannerStyle, ramp string, selfFill bool) []string {
+	if style == StylePlain || len(rows) == 0 {
+		return rows
+	}
+	g := newGrid(rows)
+	inkRamp, blank := ramp, ' '
+	if r := []rune(ramp); len(r) > 0 && r[len(r)-1] != ' ' {
+		blank = r[len(r)-1]
+		inkRamp = string(r[:len(r)-1]) + " "
+		g.replace(blank, ' ')
+	}
+	switch style {
+	case StyleShadow:
+		g = g.offset(inkRamp, effectChars(shadowRamp, ramp), [][2]int{shadowOffset})
+	case StyleOutline:
+		g = g.outline(inkRamp, effectChars(outlineRamp, ramp), selfFill)
+	case StyleExtrude:
+		g = g.offset(inkRamp, effectChars(extrudeRamp, ramp), extrudeOffsets)
+	case StyleUnderline:
+		g = g.underline([]rune(effectChars(underlineRamp, ramp))[0])
+	}
+	if blank != ' ' {
+		g.replace(' ', blank)
+	}
+	return g.rows()
+}
+
+// grid is art as a rectangle of cells
+type grid [][]rune
+
+func newGrid(rows []string) grid {
+	width := 0
+	g := make(grid, len(rows))
+	for y, row := range rows {
+		g[y] = []rune(row)
+		width = max(width, len(g[y]))
+	}
+	for y := range g {
+		for len(g[y]) < width {
+			g[y] = append(g[y], ' ')
+		}
+	}
+	return g
+}
+
+func (g grid) width() int {
+	if len(g) == 0 {
+		return 0
+	}
+	return len(g[0])
+}
+
+// ink reports whether the cell at x, y is inside the grid and not blank
+func (g grid) ink(x, y int) bool {
+	return y >= 0 && y < len(g) && x >= 0 && x < len(g[y]) && g[y][x] != ' '
+}
+
+// grow returns a copy of g with cols blank columns and rows blank rows added
+// on the right and bottom
+func (g grid) grow(cols, rows int) grid {
+	out := make(grid, len(g)+rows)
+	for y := range out {
+		out[y] = []rune(strings.Repeat(" ", g.width()+cols))
+		if y < len(g) {
+			copy(out[y], g[y])
+		}
+	}
+	return out
+}
+
+// replace swaps every cell holding old for new
+func (g grid) replace(old, new rune) {
+	for _, row := range g {
+		for x, c := range row {
+			if c == old {
+				row[x] = new
+			}
+		}
+	}
+}
+
+func (g grid) rows() []string {
+	rows := make([]string, len(g))
+	for y, row := range g {
+		rows[y] = strings.TrimRight(string(row), " ")
+	}
+	return rows
+}
+
+// offset draws copies of the letters at each offset behind them, nearest
+// first, in characters from effect
+func (g grid) offset(ramp, effect string, offsets [][2]int) grid {
+	last := offsets[len(offsets)-1]
+	out := g.grow(last[0], last[1])
+	for _, off := range offsets {
+		for y := range g {
+			for x, c := range g[y] {
+				tx, ty := x+off[0], y+off[1]
+				if c != ' ' && out[ty][tx] == ' ' {
+					out[ty][tx] = rampPick(ramp, effect, c)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// outline keeps the letter cells next to a blank cell, redrawn in outline
+// characters, and clears the rest to leave the letters hollow. Characters
+// that are not from ramp, such as the line drawing of FIGlet fonts, are
+// outlines already and are kept. Self-filled letters are hollowed but keep
+// their own characters.
+func (g grid) outline(ramp, effect string, selfFill bool) grid {
+	out := g.grow(0, 0)
+	for y := range g {
+		for x, c := range g[y] {
+			if c == ' ' || !selfFill && !strings.ContainsRune(ramp, c) {
+				continue
+			}
+			switch {
+			case g.ink(x-1, y) && g.ink(x+1, y) && g.ink(x, y-1) && g.ink(x, y+1):
+				out[y][x] = ' '
+			case !selfFill:
+				out[y][x] = rampPick(ramp, effect, c)
+			}
+		}
+	}
+	return out
+}
+
+// underline adds a rule of c below each run of rows holding letters,
+// spanning the letters in that run
+func (g grid) underline(c rune) grid {
+	var out grid
+	blank := []rune(strings.Repeat(" ", g.width()))
+	left, right := g.width(), -1
+	for y := range g {
+		out = append(out, g[y])
+		for x := range g[y] {
+			if g.ink(x, y) {
+				left, right = min(left, x), max(right, x)
+			}
+		}
+		if right >= 0 && (y == len(g)-1 || !g.rowInk(y+1)) {
+			rule := append([]rune(nil), blank...)
+			for x := left; x <= right; x++ {
+				rule[x] = c
+			}
+			out = append(out, rule)
+			left, right = g.width(), -1
+		}
+	}
+	return out
+}
+
+func (g grid) rowInk(y int) bool {
+	return strings.TrimSpace(string(g[y])) != ""
+}
+
+// rampPick returns the character of effect at the density c has in ramp.
+// Characters not in ramp are treated as the darkest.
+func rampPick(ramp, effect string, c rune) rune {
+	inkRamp := []rune(strings.ReplaceAll(ramp, " ", ""))
+	e := []rune(effect)
+	for i, r := range inkRamp {
+		if r == c && len(inkRamp) > 1 {
+			return e[(i*(len(e)-1)+(len(inkRamp)-1)/2)/(len(inkRamp)-1)]
+		}
+	}
+	return e[0]
+}
+
+// invertRows swaps each character of ramp for the one at the mirrored
+// position, as a reversed ramp would have drawn it, after padding rows to
+// the same width. Characters not in ramp, such as effects, are kept.
+func invertRows(rows []string, ramp string) []string {
+	r := []rune(ramp)
+	g := newGrid(rows)
+	out := make([]string, len(g))
+	for y, row := range g {
+		for x, c := range row {
+			for i, rc := range r {
+				if rc == c {
+					row[x] = r[len(r)-1-i]
+					break
+				}
+			}
+		}
+		out[y] = string(row)
+	}
+	return out
+}
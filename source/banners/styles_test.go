@@ -0,0 +1,108 @@
+package banners
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyStyle(t *testing.T) {
+	const ramp = "@#*+=-:. "
+	block := []string{
+		"@@@",
+		"@@@",
+		"@@:",
+	}
+	tests := []struct {
+		style    BannerStyle
+		rows     []string
+		ramp     string
+		selfFill bool
+		want     []string
+	}{
+		{StylePlain, block, ramp, false, block},
+		{StyleShadow, block, ramp, false, []string{
+			"@@@",
+			"@@@;;",
+			"@@:;;",
+			"  ;;,",
+		}},
+		{StyleOutline, block, ramp, false, []string{
+			"%%%",
+			"% %",
+			"%%o",
+		}},
+		{StyleExtrude, []string{"@"}, ramp, false, []string{
+			"@",
+			" //",
+			"   //",
+		}},
+		{StyleUnderline, []string{" @ @", "", "@"}, ramp, false, []string{
+			" @ @",
+			" ~~~",
+			"",
+			"@",
+			"~",
+		}},
+		// FIGlet line drawing is already an outline
+		{StyleOutline, []string{"|_|", "|_|"}, "", false, []string{"|_|", "|_|"}},
+		{StyleShadow, []string{"|"}, "", false, []string{"|", "  ;"}},
+		{StyleOutline, []string{"HHH", "HHH", "HHH"}, ramp, true, []string{"HHH", "H H", "HHH"}},
+		// A ramp without a space fills the background with its lightest
+		// character, which the styles treat as blank
+		{StyleShadow, []string{"....", ".##.", ".##.", "...."}, "#+.", false, []string{
+			"......",
+			".##...",
+			".##;;.",
+			"...;;.",
+			"......",
+		}},
+		{StyleOutline, []string{".....", ".###.", ".###.", ".###.", "....."}, "#+.", false, []string{
+			".....",
+			".%%%.",
+			".%.%.",
+			".%%%.",
+			".....",
+		}},
+	}
+	for _, tt := range tests {
+		if got := applyStyle(tt.rows, tt.style, tt.ramp, tt.selfFill); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("applyStyle(%q, %v) = %q, want %q", tt.rows, tt.style, got, tt.want)
+		}
+	}
+}
+
+func TestParseBannerStyle(t *testing.T) {
+	for s := StylePlain; s <= StyleUnderline; s++ {
+		if got, err := ParseBannerStyle(s.String()); err != nil || got != s {
+			t.Errorf("ParseBannerStyle(%q) = %v, %v", s.String(), got, err)
+		}
+	}
+	if _, err := ParseBannerStyle("sparkle"); err == nil {
+		t.Error("Expected error for unknown style")
+	}
+}
+
+func TestEffectChars(t *testing.T) {
+	tests := []struct {
+		effect, ramp, want string
+	}{
+		{shadowRamp, "@#*+=-:. ", ";,"},
+		{shadowRamp, "#;, ", "%o"},
+		{outlineRamp, "%@ ", ";o"},
+		{underlineRamp, "~# ", ";"},
+		{shadowRamp, "", ";,"},
+	}
+	for _, tt := range tests {
+		if got := effectChars(tt.effect, tt.ramp); got != tt.want {
+			t.Errorf("effectChars(%q, %q) = %q, want %q", tt.effect, tt.ramp, got, tt.want)
+		}
+	}
+}
+
+func TestInvertRows(t *testing.T) {
+	rows := []string{"@# ;", "@"}
+	want := []string{" #@;", " @@@"}
+	if got := invertRows(rows, "@# "); !reflect.DeepEqual(got, want) {
+		t.Errorf("invertRows() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,852 @@
+package banners
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// Registered axis tags of variable fonts
+const (
+	AxisWeight = "wght" // 1-1000, 400 is regular and 700 bold
+	AxisWidth  = "wdth" // percent of the normal width
+)
+
+// Axis is a design axis of a variable font, in the axis's own units
+type Axis struct {
+	Tag     string  `json:"tag"`
+	Name    string  `json:"name"`
+	Min     float64 `json:"min"`
+	Default float64 `json:"default"`
+	Max     float64 `json:"max"`
+}
+
+// Variation selects an instance of a variable font by axis tag. Axes it
+// does not name keep their defaults.
+type Variation map[string]float64
+
+// maxComponentDepth limits how deeply composite glyphs may nest
+const maxComponentDepth = 8
+
+// varFont holds what is needed to draw the instances of a TrueType variable
+// font: its axes, the avar maps that adjust them, the glyph outlines and
+// the gvar deltas that move their points. CFF2 fonts are not supported.
+type varFont struct {
+	axes         []Axis
+	avar         [][][2]float64 // per axis, from and to coordinates; empty for none
+	glyf         []byte
+	loca         []int // numGlyphs+1 offsets into glyf
+	hmtx         []byte
+	numHMetrics  int
+	gvar         []byte
+	gvarOffsets  []int // numGlyphs+1 offsets into gvar
+	sharedTuples [][]float64
+}
+
+// reader reads big-endian values from a font table. Reading past the end
+// yields zeros and sets bad, so a parse checks once when it is done.
+type reader struct {
+	b   []byte
+	off int
+	bad bool
+}
+
+func (r *reader) next(n int) []byte {
+	if r.bad || n < 0 || n > len(r.b)-r.off {
+		r.bad = true
+		return nil
+	}
+	b := r.b[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *reader) u8() uint8 {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) u16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) u32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *reader) i16() int16 { return int16(r.u16()) }
+
+func (r *reader) f2dot14() float64 { return float64(r.i16()) / (1 << 14) }
+
+func (r *reader) fixed() float64 { return float64(int32(r.u32())) / (1 << 16) }
+
+func (r *reader) tuple(axes int) []float64 {
+	t := make([]float64, axes)
+	for i := range t {
+		t[i] = r.f2dot14()
+	}
+	return t
+}
+
+// points reads packed point numbers, returning nil for all points
+func (r *reader) points() []int {
+	n := int(r.u8())
+	if n == 0 {
+		return nil
+	}
+	if n&0x80 != 0 {
+		n = (n&0x7f)<<8 | int(r.u8())
+	}
+	pts := make([]int, 0, n)
+	p := 0
+	for len(pts) < n && !r.bad {
+		ctl := r.u8()
+		for i := 0; i <= int(ctl&0x7f) && len(pts) < n; i++ {
+			if ctl&0x80 != 0 {
+				p += int(r.u16())
+			} else {
+				p += int(r.u8())
+			}
+			pts = append(pts, p)
+		}
+	}
+	return pts
+}
+
+// deltas reads n packed deltas
+func (r *reader) deltas(n int) []float64 {
+	d := make([]float64, 0, n)
+	for len(d) < n && !r.bad {
+		ctl := r.u8()
+		for i := 0; i <= int(ctl&0x3f) && len(d) < n; i++ {
+			switch ctl & 0xc0 {
+			case 0x80:
+				d = append(d, 0)
+			case 0x40:
+				d = append(d, float64(r.i16()))
+			case 0xc0:
+				d = append(d, float64(int32(r.u32())))
+			default:
+				d = append(d, float64(int8(r.u8())))
+			}
+		}
+	}
+	return d
+}
+
+// sfntTables returns the tables of an sfnt font by tag
+func sfntTables(data []byte) (map[string][]byte, error) {
+	r := &reader{b: data, off: 4}
+	n := int(r.u16())
+	r.off = 12
+	tables := make(map[string][]byte, n)
+	for range n {
+		tag := string(r.next(4))
+		r.u32() // checksum
+		off, length := int(r.u32()), int(r.u32())
+		if r.bad || off > len(data) || length > len(data)-off {
+			return nil, fmt.Errorf("malformed table directory")
+		}
+		tables[tag] = data[off : off+length]
+	}
+	return tables, nil
+}
+
+// parseVarFont reads the variation data of a TrueType font, returning nil
+// for fonts without axes or with CFF2 outlines
+func parseVarFont(data []byte, f *sfnt.Font) (*varFont, error) {
+	tables, err := sfntTables(data)
+	if err != nil {
+		return nil, err
+	}
+	fvar, gvar, glyf := tables["fvar"], tables["gvar"], tables["glyf"]
+	if fvar == nil || gvar == nil || glyf == nil {
+		return nil, nil
+	}
+	v := &varFont{glyf: glyf, hmtx: tables["hmtx"]}
+
+	var buf sfnt.Buffer
+	r := &reader{b: fvar, off: 4}
+	axesOff, _, count, size := int(r.u16()), r.u16(), int(r.u16()), int(r.u16())
+	for i := range count {
+		ar := &reader{b: fvar, off: axesOff + i*size}
+		a := Axis{Tag: string(ar.next(4)), Min: ar.fixed(), Default: ar.fixed(), Max: ar.fixed()}
+		ar.u16() // flags
+		nameID := sfnt.NameID(ar.u16())
+		if ar.bad || !(a.Min <= a.Default && a.Default <= a.Max) {
+			return nil, fmt.Errorf("malformed fvar table")
+		}
+		if a.Name, _ = f.Name(&buf, nameID); a.Name == "" {
+			a.Name = a.Tag
+		}
+		v.axes = append(v.axes, a)
+	}
+
+	v.avar = make([][][2]float64, len(v.axes))
+	if avar := tables["avar"]; avar != nil {
+		r := &reader{b: avar, off: 6}
+		if int(r.u16()) == len(v.axes) {
+			for i := range v.axes {
+				m := make([][2]float64, r.u16())
+				for j := range m {
+					m[j] = [2]float64{r.f2dot14(), r.f2dot14()}
+				}
+				v.avar[i] = m
+			}
+		}
+		if r.bad {
+			return nil, fmt.Errorf("malformed avar table")
+		}
+	}
+
+	head, maxp, hhea := &reader{b: tables["head"], off: 50}, &reader{b: tables["maxp"], off: 4}, &reader{b: tables["hhea"], off: 34}
+	longLoca, numGlyphs := head.i16() != 0, int(maxp.u16())
+	v.numHMetrics = int(hhea.u16())
+	if head.bad || maxp.bad || hhea.bad || v.numHMetrics == 0 || len(v.hmtx) < 4*v.numHMetrics {
+		return nil, fmt.Errorf("malformed head, maxp, hhea or hmtx table")
+	}
+	lr := &reader{b: tables["loca"]}
+	v.loca = make([]int, numGlyphs+1)
+	for i := range v.loca {
+		if longLoca {
+			v.loca[i] = int(lr.u32())
+		} else {
+			v.loca[i] = 2 * int(lr.u16())
+		}
+		if i > 0 && v.loca[i] < v.loca[i-1] || v.loca[i] > len(glyf) {
+			return nil, fmt.Errorf("malformed loca table")
+		}
+	}
+	if lr.bad {
+		return nil, fmt.Errorf("malformed loca table")
+	}
+
+	r = &reader{b: gvar, off: 4}
+	axisCount, sharedCount, sharedOff := int(r.u16()), int(r.u16()), int(r.u32())
+	glyphCount, flags, dataOff := int(r.u16()), r.u16(), int(r.u32())
+	if r.bad || axisCount != len(v.axes) || glyphCount > numGlyphs || dataOff > len(gvar) {
+		return nil, fmt.Errorf("malformed gvar table")
+	}
+	v.gvar = gvar[dataOff:]
+	v.gvarOffsets = make([]int, glyphCount+1)
+	for i := range v.gvarOffsets {
+		if flags&1 != 0 {
+			v.gvarOffsets[i] = int(r.u32())
+		} else {
+			v.gvarOffsets[i] = 2 * int(r.u16())
+		}
+		if i > 0 && v.gvarOffsets[i] < v.gvarOffsets[i-1] || v.gvarOffsets[i] > len(v.gvar) {
+			return nil, fmt.Errorf("malformed gvar table")
+		}
+	}
+	sr := &reader{b: gvar, off: sharedOff}
+	for range sharedCount {
+		v.sharedTuples = append(v.sharedTuples, sr.tuple(axisCount))
+	}
+	if r.bad || sr.bad {
+		return nil, fmt.Errorf("malformed gvar table")
+	}
+	return v, nil
+}
+
+// normalize maps a variation to the coordinates deltas are keyed by: -1 at
+// each axis's minimum, 0 at its default and 1 at its maximum, adjusted by
+// the avar table
+func (f *varFont) normalize(v Variation) ([]float64, error) {
+	for tag := range v {
+		if f.axis(tag) < 0 {
+			return nil, fmt.Errorf("no %s axis", tag)
+		}
+	}
+	coords := make([]float64, len(f.axes))
+	for i, a := range f.axes {
+		value, ok := v[a.Tag]
+		if !ok {
+			continue
+		}
+		if !(value >= a.Min && value <= a.Max) {
+			return nil, fmt.Errorf("%s must be between %g and %g", a.Tag, a.Min, a.Max)
+		}
+		var c float64
+		switch {
+		case value < a.Default:
+			c = (value - a.Default) / (a.Default - a.Min)
+		case value > a.Default:
+			c = (value - a.Default) / (a.Max - a.Default)
+		}
+		if m := f.avar[i]; len(m) > 1 {
+			c = avarMap(m, c)
+		}
+		coords[i] = math.Round(c*(1<<14)) / (1 << 14)
+	}
+	return coords, nil
+}
+
+func (f *varFont) axis(tag string) int {
+	for i, a := range f.axes {
+		if a.Tag == tag {
+			return i
+		}
+	}
+	return -1
+}
+
+// clamp returns the part of v on axes the font has, limited to their
+// ranges
+func (f *varFont) clamp(v Variation) Variation {
+	out := Variation{}
+	for tag, value := range v {
+		if i := f.axis(tag); i >= 0 && !math.IsNaN(value) {
+			out[tag] = min(max(value, f.axes[i].Min), f.axes[i].Max)
+		}
+	}
+	return out
+}
+
+// avarMap interpolates c in a segment map of from and to coordinates
+func avarMap(m [][2]float64, c float64) float64 {
+	for j := 1; j < len(m); j++ {
+		if c <= m[j][0] {
+			a, b := m[j-1], m[j]
+			if a[0] == b[0] {
+				return b[1]
+			}
+			return a[1] + (b[1]-a[1])*(c-a[0])/(b[0]-a[0])
+		}
+	}
+	return c
+}
+
+// varPoint is a point of a glyph outline in font units
+type varPoint struct {
+	x, y float64
+	on   bool // on the curve rather than a quadratic control point
+}
+
+// varGlyph is a glyph outline at an instance. Ends holds the index after
+// the last point of each contour; left and right are the x coordinates of
+// the origin and advance, which variations may move.
+type varGlyph struct {
+	points      []varPoint
+	ends        []int
+	left, right float64
+}
+
+// glyph loads glyph g at coords
+func (f *varFont) glyph(g sfnt.GlyphIndex, coords []float64, depth int) (*varGlyph, error) {
+	if depth > maxComponentDepth {
+		return nil, fmt.Errorf("composite glyphs nested too deeply")
+	}
+	if int(g)+1 >= len(f.loca) {
+		return nil, fmt.Errorf("glyph %d out of range", g)
+	}
+	hr := &reader{b: f.hmtx, off: 4 * min(int(g), f.numHMetrics-1)}
+	advance := float64(hr.u16())
+	if int(g) >= f.numHMetrics {
+		hr.off = 4*f.numHMetrics + 2*(int(g)-f.numHMetrics)
+	}
+	lsb := float64(hr.i16())
+	if hr.bad {
+		return nil, fmt.Errorf("glyph %d has no metrics", g)
+	}
+
+	data := f.glyf[f.loca[g]:f.loca[g+1]]
+	r := &reader{b: data}
+	contours, xMin := 0, 0.0
+	if len(data) > 0 {
+		contours, xMin = int(r.i16()), float64(r.i16())
+		r.off = 10
+	}
+	out := &varGlyph{left: xMin - lsb}
+	out.right = out.left + advance
+	if contours >= 0 {
+		out.points, out.ends = simpleGlyph(r, contours)
+		if r.bad {
+			return nil, fmt.Errorf("malformed glyph %d", g)
+		}
+		dx, dy, err := f.deltas(g, coords, len(out.points)+4, out.points, out.ends)
+		if err != nil {
+			return nil, err
+		}
+		for i := range out.points {
+			out.points[i].x += dx[i]
+			out.points[i].y += dy[i]
+		}
+		n := len(out.points)
+		out.left, out.right = out.left+dx[n], out.right+dx[n+1]
+		return out, nil
+	}
+
+	comps := compositeGlyph(r)
+	if r.bad {
+		return nil, fmt.Errorf("malformed glyph %d", g)
+	}
+	dx, dy, err := f.deltas(g, coords, len(comps)+4, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range comps {
+		sub, err := f.glyph(c.glyph, coords, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		for j, p := range sub.points {
+			sub.points[j].x, sub.points[j].y = c.m[0]*p.x+c.m[2]*p.y, c.m[1]*p.x+c.m[3]*p.y
+		}
+		offX, offY := c.arg1+dx[i], c.arg2+dy[i]
+		if !c.xy {
+			// Match a point of the glyph so far with one of the component
+			parent, child := int(c.arg1), int(c.arg2)
+			if parent >= len(out.points) || child >= len(sub.points) {
+				return nil, fmt.Errorf("malformed glyph %d", g)
+			}
+			offX, offY = out.points[parent].x-sub.points[child].x, out.points[parent].y-sub.points[child].y
+		}
+		base := len(out.points)
+		for _, p := range sub.points {
+			out.points = append(out.points, varPoint{p.x + offX, p.y + offY, p.on})
+		}
+		for _, e := range sub.ends {
+			out.ends = append(out.ends, base+e)
+		}
+	}
+	n := len(comps)
+	out.left, out.right = out.left+dx[n], out.right+dx[n+1]
+	return out, nil
+}
+
+// simpleGlyph reads the contours of a simple glyph, r being positioned
+// after the glyph header
+func simpleGlyph(r *reader, contours int) ([]varPoint, []int) {
+	ends := make([]int, contours)
+	for i := range ends {
+		ends[i] = int(r.u16()) + 1
+		if i > 0 && ends[i] <= ends[i-1] {
+			r.bad = true
+		}
+	}
+	if contours == 0 || r.bad {
+		return nil, nil
+	}
+	n := ends[contours-1]
+	r.next(int(r.u16())) // instructions
+
+	flags := make([]uint8, 0, n)
+	for len(flags) < n && !r.bad {
+		fl := r.u8()
+		flags = append(flags, fl)
+		if fl&0x08 != 0 {
+			for repeat := r.u8(); repeat > 0 && len(flags) < n; repeat-- {
+				flags = append(flags, fl)
+			}
+		}
+	}
+	if r.bad {
+		return nil, nil
+	}
+	points := make([]varPoint, n)
+	// Coordinates are deltas from the previous point: one byte with a
+	// sign flag, or the same as before, or two bytes
+	coord := func(fl, short, same uint8) float64 {
+		switch {
+		case fl&short != 0 && fl&same != 0:
+			return float64(r.u8())
+		case fl&short != 0:
+			return -float64(r.u8())
+		case fl&same != 0:
+			return 0
+		default:
+			return float64(r.i16())
+		}
+	}
+	x, y := 0.0, 0.0
+	for i, fl := range flags {
+		x += coord(fl, 0x02, 0x10)
+		points[i] = varPoint{x: x, on: fl&0x01 != 0}
+	}
+	for i, fl := range flags {
+		y += coord(fl, 0x04, 0x20)
+		points[i].y = y
+	}
+	return points, ends
+}
+
+// component is a glyph placed within a composite glyph. Arg1 and arg2 are
+// its offset when xy is set, and otherwise the points matched to place it.
+type component struct {
+	glyph      sfnt.GlyphIndex
+	xy         bool
+	arg1, arg2 float64
+	m          [4]float64 // x', y' = m[0]x + m[2]y, m[1]x + m[3]y
+}
+
+func compositeGlyph(r *reader) []component {
+	var comps []component
+	for more := true; more && !r.bad; {
+		fl := r.u16()
+		c := component{glyph: sfnt.GlyphIndex(r.u16()), xy: fl&0x0002 != 0, m: [4]float64{1, 0, 0, 1}}
+		switch {
+		case fl&0x0001 != 0 && c.xy:
+			c.arg1, c.arg2 = float64(r.i16()), float64(r.i16())
+		case fl&0x0001 != 0:
+			c.arg1, c.arg2 = float64(r.u16()), float64(r.u16())
+		case c.xy:
+			c.arg1, c.arg2 = float64(int8(r.u8())), float64(int8(r.u8()))
+		default:
+			c.arg1, c.arg2 = float64(r.u8()), float64(r.u8())
+		}
+		switch {
+		case fl&0x0008 != 0:
+			s := r.f2dot14()
+			c.m[0], c.m[3] = s, s
+		case fl&0x0040 != 0:
+			c.m[0], c.m[3] = r.f2dot14(), r.f2dot14()
+		case fl&0x0080 != 0:
+			c.m = [4]float64{r.f2dot14(), r.f2dot14(), r.f2dot14(), r.f2dot14()}
+		}
+		comps = append(comps, c)
+		more = fl&0x0020 != 0
+	}
+	return comps
+}
+
+// deltas returns how far each of a glyph's n points, including its four
+// phantom points, moves at coords. Points and ends are the glyph's default
+// outline, from which the deltas of points a tuple does not move are
+// inferred; they are nil for composite glyphs, whose unmoved components
+// stay put.
+func (f *varFont) deltas(g sfnt.GlyphIndex, coords []float64, n int, points []varPoint, ends []int) (dx, dy []float64, err error) {
+	dx, dy = make([]float64, n), make([]float64, n)
+	if int(g)+1 >= len(f.gvarOffsets) || f.gvarOffsets[g] == f.gvarOffsets[g+1] {
+		return dx, dy, nil
+	}
+	data := f.gvar[f.gvarOffsets[g]:f.gvarOffsets[g+1]]
+	malformed := fmt.Errorf("malformed variations for glyph %d", g)
+
+	r := &reader{b: data}
+	count, dataOff := r.u16(), int(r.u16())
+	serial := &reader{b: data, off: dataOff}
+	var shared []int
+	if count&0x8000 != 0 {
+		shared = serial.points()
+	}
+	for range count & 0x0fff {
+		size, index := int(r.u16()), r.u16()
+		var peak, start, end []float64
+		if index&0x8000 != 0 {
+			peak = r.tuple(len(f.axes))
+		} else if i := int(index & 0x0fff); i < len(f.sharedTuples) {
+			peak = f.sharedTuples[i]
+		} else {
+			return nil, nil, malformed
+		}
+		if index&0x4000 != 0 {
+			start, end = r.tuple(len(f.axes)), r.tuple(len(f.axes))
+		}
+		tr := &reader{b: serial.next(size)}
+		if r.bad || serial.bad {
+			return nil, nil, malformed
+		}
+		scalar := tupleScalar(coords, peak, start, end)
+		if scalar == 0 {
+			continue
+		}
+
+		pts := shared
+		if index&0x2000 != 0 {
+			pts = tr.points()
+		}
+		m := len(pts)
+		if pts == nil {
+			m = n
+		}
+		xs, ys := tr.deltas(m), tr.deltas(m)
+		if tr.bad {
+			return nil, nil, malformed
+		}
+		if pts == nil {
+			for i := range n {
+				dx[i] += scalar * xs[i]
+				dy[i] += scalar * ys[i]
+			}
+			continue
+		}
+		tx, ty, touched := make([]float64, n), make([]float64, n), make([]bool, n)
+		for j, p := range pts {
+			if p < n {
+				tx[p], ty[p], touched[p] = xs[j], ys[j], true
+			}
+		}
+		if points != nil {
+			interpolate(tx, ty, touched, points, ends)
+		}
+		for i := range n {
+			dx[i] += scalar * tx[i]
+			dy[i] += scalar * ty[i]
+		}
+	}
+	return dx, dy, nil
+}
+
+// tupleScalar returns how much of a tuple's deltas apply at coords: 1 at
+// its peak, falling to 0 at the edges of its region
+func tupleScalar(coords, peak, start, end []float64) float64 {
+	s := 1.0
+	for i, p := range peak {
+		c := coords[i]
+		if p == 0 || c == p {
+			continue
+		}
+		if start == nil {
+			if c == 0 || c < min(0, p) || c > max(0, p) {
+				return 0
+			}
+			s *= c / p
+			continue
+		}
+		lo, hi := start[i], end[i]
+		if lo > p || p > hi || lo < 0 && hi > 0 {
+			continue
+		}
+		if c < lo || c > hi {
+			return 0
+		}
+		if c < p {
+			s *= (c - lo) / (p - lo)
+		} else {
+			s *= (hi - c) / (hi - p)
+		}
+	}
+	return s
+}
+
+// interpolate infers the deltas of the points of each contour a tuple does
+// not move from the nearest moved points on either side, as gvar requires
+func interpolate(dx, dy []float64, touched []bool, points []varPoint, ends []int) {
+	start := 0
+	for _, end := range ends {
+		var moved []int
+		for i := start; i < end; i++ {
+			if touched[i] {
+				moved = append(moved, i)
+			}
+		}
+		next := func(i int) int {
+			if i+1 == end {
+				return start
+			}
+			return i + 1
+		}
+		if len(moved) < end-start {
+			for k, p1 := range moved {
+				p2 := moved[(k+1)%len(moved)]
+				for i := next(p1); i != p2; i = next(i) {
+					dx[i] = inferDelta(points[i].x, points[p1].x, points[p2].x, dx[p1], dx[p2])
+					dy[i] = inferDelta(points[i].y, points[p1].y, points[p2].y, dy[p1], dy[p2])
+				}
+			}
+		}
+		start = end
+	}
+}
+
+func inferDelta(c, c1, c2, d1, d2 float64) float64 {
+	if c1 == c2 {
+		if d1 == d2 {
+			return d1
+		}
+		return 0
+	}
+	if c1 > c2 {
+		c1, c2, d1, d2 = c2, c1, d2, d1
+	}
+	switch {
+	case c <= c1:
+		return d1
+	case c >= c2:
+		return d2
+	}
+	return d1 + (c-c1)*(d2-d1)/(c2-c1)
+}
+
+// varFace draws an instance of a variable font. Metrics and kerning come
+// from base, a face of the default instance at the same size, and advances
+// are rounded to whole pixels like the registry's other faces.
+type varFace struct {
+	font   *varFont
+	sfnt   *sfnt.Font
+	base   font.Face
+	coords []float64
+	scale  float64 // pixels per font unit
+	buf    sfnt.Buffer
+	glyphs map[sfnt.GlyphIndex]*varGlyph
+	rast   vector.Rasterizer
+}
+
+func newVarFace(f *varFont, sf *sfnt.Font, base font.Face, size float64, coords []float64) *varFace {
+	return &varFace{
+		font:   f,
+		sfnt:   sf,
+		base:   base,
+		coords: coords,
+		scale:  size / float64(sf.UnitsPerEm()),
+		glyphs: make(map[sfnt.GlyphIndex]*varGlyph),
+	}
+}
+
+// load returns the outline of r's glyph and its index, or false if it
+// cannot be drawn
+func (f *varFace) load(r rune) (*varGlyph, sfnt.GlyphIndex, bool) {
+	x, err := f.sfnt.GlyphIndex(&f.buf, r)
+	if err != nil {
+		return nil, 0, false
+	}
+	g, ok := f.glyphs[x]
+	if !ok {
+		g, _ = f.font.glyph(x, f.coords, 0)
+		f.glyphs[x] = g
+	}
+	return g, x, g != nil
+}
+
+func (f *varFace) advance(g *varGlyph) fixed.Int26_6 {
+	return fixed.I(int(math.Round((g.right - g.left) * f.scale)))
+}
+
+// bounds returns the glyph's bounds in pixels relative to its origin, with
+// y growing down
+func (f *varFace) bounds(g *varGlyph) fixed.Rectangle26_6 {
+	if len(g.points) == 0 {
+		return fixed.Rectangle26_6{}
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range g.points {
+		x, y := (p.x-g.left)*f.scale, -p.y*f.scale
+		minX, minY = min(minX, x), min(minY, y)
+		maxX, maxY = max(maxX, x), max(maxY, y)
+	}
+	return fixed.Rectangle26_6{
+		Min: fixed.Point26_6{X: fixed.Int26_6(math.Floor(minX * 64)), Y: fixed.Int26_6(math.Floor(minY * 64))},
+		Max: fixed.Point26_6{X: fixed.Int26_6(math.Ceil(maxX * 64)), Y: fixed.Int26_6(math.Ceil(maxY * 64))},
+	}
+}
+
+func (f *varFace) Close() error {
+	return f.base.Close()
+}
+
+func (f *varFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	g, x, ok := f.load(r)
+	if !ok {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+	b := f.bounds(g).Add(dot)
+	dr := image.Rect(b.Min.X.Floor(), b.Min.Y.Floor(), b.Max.X.Ceil(), b.Max.Y.Ceil())
+	mask := image.NewAlpha(image.Rect(0, 0, dr.Dx(), dr.Dy()))
+	if !dr.Empty() {
+		biasX := float64(dot.X)/64 - float64(dr.Min.X) - g.left*f.scale
+		biasY := float64(dot.Y)/64 - float64(dr.Min.Y)
+		f.rast.Reset(dr.Dx(), dr.Dy())
+		f.rast.DrawOp = draw.Src
+		g.draw(&f.rast, func(p varPoint) (float32, float32) {
+			return float32(p.x*f.scale + biasX), float32(biasY - p.y*f.scale)
+		})
+		f.rast.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+	}
+	return dr, mask, image.Point{}, f.advance(g), x != 0
+}
+
+func (f *varFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	g, x, ok := f.load(r)
+	if !ok {
+		return fixed.Rectangle26_6{}, 0, false
+	}
+	return f.bounds(g), f.advance(g), x != 0
+}
+
+func (f *varFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	g, x, ok := f.load(r)
+	if !ok {
+		return 0, false
+	}
+	return f.advance(g), x != 0
+}
+
+func (f *varFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	return f.base.Kern(r0, r1)
+}
+
+func (f *varFace) Metrics() font.Metrics {
+	return f.base.Metrics()
+}
+
+// draw traces the glyph's quadratic contours with pt mapping points to the
+// rasterizer
+func (g *varGlyph) draw(z *vector.Rasterizer, pt func(varPoint) (float32, float32)) {
+	mid := func(a, b varPoint) varPoint {
+		return varPoint{x: (a.x + b.x) / 2, y: (a.y + b.y) / 2, on: true}
+	}
+	quad := func(ctrl, to varPoint) {
+		cx, cy := pt(ctrl)
+		tx, ty := pt(to)
+		z.QuadTo(cx, cy, tx, ty)
+	}
+	start := 0
+	for _, end := range g.ends {
+		contour := g.points[start:end]
+		start = end
+		if len(contour) == 0 {
+			continue
+		}
+		// Start on the curve: at the first point, the last, or between
+		// them when both are control points
+		first, last := contour[0], contour[len(contour)-1]
+		var from varPoint
+		switch {
+		case first.on:
+			from, contour = first, contour[1:]
+		case last.on:
+			from, contour = last, contour[:len(contour)-1]
+		default:
+			from = mid(last, first)
+		}
+		z.MoveTo(pt(from))
+		var ctrl varPoint
+		pending := false
+		for _, p := range contour {
+			switch {
+			case p.on && pending:
+				quad(ctrl, p)
+				pending = false
+			case p.on:
+				z.LineTo(pt(p))
+			case pending:
+				quad(ctrl, mid(ctrl, p))
+				ctrl = p
+			default:
+				ctrl, pending = p, true
+			}
+		}
+		if pending {
+			quad(ctrl, from)
+		} else {
+			z.LineTo(pt(from))
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package banners
+
+import (
+	"image"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+const variableFont = Font("SourceCodePro-Italic-VariableFont_wght")
+
+func TestFontAxes(t *testing.T) {
+	for _, f := range DefaultFonts().Fonts() {
+		switch f.Name {
+		case string(variableFont):
+			want := []Axis{{Tag: AxisWeight, Name: "Weight", Min: 200, Default: 200, Max: 900}}
+			if !reflect.DeepEqual(f.Axes, want) {
+				t.Errorf("Axes = %+v, want %+v", f.Axes, want)
+			}
+		default:
+			if len(f.Axes) != 0 {
+				t.Errorf("%s has axes %+v", f.Name, f.Axes)
+			}
+		}
+	}
+}
+
+func TestValidateVariation(t *testing.T) {
+	tests := []struct {
+		font    Font
+		v       Variation
+		wantErr bool
+	}{
+		{variableFont, nil, false},
+		{variableFont, Variation{AxisWeight: 700}, false},
+		{variableFont, Variation{AxisWeight: 200}, false},
+		{variableFont, Variation{AxisWeight: 100}, true},
+		{variableFont, Variation{AxisWeight: math.NaN()}, true},
+		{variableFont, Variation{AxisWidth: 75}, true},
+		{"SourceCodePro-Regular", nil, false},
+		{"SourceCodePro-Regular", Variation{AxisWeight: 700}, true},
+		{"standard", nil, false},
+		{"standard", Variation{AxisWeight: 700}, true},
+		{"Missing", nil, true},
+	}
+	for _, tt := range tests {
+		if err := DefaultFonts().ValidateVariation(tt.font, tt.v); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateVariation(%s, %v) error = %v, wantErr %v", tt.font, tt.v, err, tt.wantErr)
+		}
+	}
+}
+
+// ink sums the coverage of a glyph's mask
+func ink(t *testing.T, face font.Face, r rune) (int, image.Rectangle) {
+	t.Helper()
+	dr, mask, _, _, ok := face.Glyph(fixed.P(20, 60), r)
+	if !ok {
+		t.Fatalf("Glyph(%q) not ok", r)
+	}
+	sum := 0
+	for _, p := range mask.(*image.Alpha).Pix {
+		sum += int(p)
+	}
+	return sum, dr
+}
+
+func TestFaceVariation(t *testing.T) {
+	r := DefaultFonts()
+	e, err := r.entry(variableFont)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := r.Face(variableFont, 48)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer base.Close()
+
+	// At the default instance the outlines match those drawn by opentype
+	zero := newVarFace(e.vf, e.font, base, 48, make([]float64, len(e.vf.axes)))
+	for _, c := range "aHg&é" {
+		want, wantRect := ink(t, base, c)
+		got, gotRect := ink(t, zero, c)
+		if gotRect != wantRect || math.Abs(float64(got-want)) > 0.01*float64(want) {
+			t.Errorf("%q: ink %d in %v, want %d in %v", c, got, gotRect, want, wantRect)
+		}
+	}
+
+	light, err := r.FaceVariation(variableFont, 48, Variation{AxisWeight: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer light.Close()
+	bold, err := r.FaceVariation(variableFont, 48, Variation{AxisWeight: 900})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bold.Close()
+	for _, c := range "aHé" {
+		thin, _ := ink(t, light, c)
+		thick, _ := ink(t, bold, c)
+		if thick < thin*3/2 {
+			t.Errorf("%q: bold ink %d not heavier than light %d", c, thick, thin)
+		}
+		la, _ := light.GlyphAdvance(c)
+		ba, ok := bold.GlyphAdvance(c)
+		if !ok || ba != la {
+			t.Errorf("%q: bold advance %v, light %v; the font is monospaced", c, ba, la)
+		}
+	}
+	if _, ok := bold.GlyphAdvance('漢'); ok {
+		t.Error("Expected GlyphAdvance not ok for a rune the font lacks")
+	}
+	if _, err := r.FaceVariation(variableFont, 48, Variation{AxisWeight: 1000}); err == nil {
+		t.Error("Expected error for a weight out of range")
+	}
+}
+
+func TestTupleScalar(t *testing.T) {
+	tests := []struct {
+		coords, peak, start, end []float64
+		want                     float64
+	}{
+		{[]float64{0.5}, []float64{1}, nil, nil, 0.5},
+		{[]float64{1}, []float64{1}, nil, nil, 1},
+		{[]float64{-0.5}, []float64{1}, nil, nil, 0},
+		{[]float64{0}, []float64{0}, nil, nil, 1},
+		{[]float64{0.5, 0.5}, []float64{1, 0.5}, nil, nil, 0.5},
+		{[]float64{0.25}, []float64{0.5}, []float64{0}, []float64{1}, 0.5},
+		{[]float64{0.75}, []float64{0.5}, []float64{0}, []float64{1}, 0.5},
+		{[]float64{0.25}, []float64{0.5}, []float64{0.4}, []float64{1}, 0},
+	}
+	for _, tt := range tests {
+		if got := tupleScalar(tt.coords, tt.peak, tt.start, tt.end); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("tupleScalar(%v, %v, %v, %v) = %v, want %v", tt.coords, tt.peak, tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	// A square with its left corners moved right by 10 and its right
+	// corners by 30; the points between take deltas by position
+	points := []varPoint{{x: 0, y: 0}, {x: 50, y: 0}, {x: 100, y: 0}, {x: 100, y: 100}, {x: 0, y: 100}, {x: 150, y: 50}}
+	dx := []float64{10, 0, 30, 30, 10, 0}
+	dy := make([]float64, len(points))
+	touched := []bool{true, false, true, true, true, false}
+	interpolate(dx, dy, touched, points, []int{5, 6})
+	if want := []float64{10, 20, 30, 30, 10, 0}; !reflect.DeepEqual(dx, want) {
+		t.Errorf("interpolate() dx = %v, want %v", dx, want)
+	}
+
+	// A single moved point shifts its whole contour
+	dx = []float64{0, 5, 0}
+	touched = []bool{false, true, false}
+	interpolate(dx, make([]float64, 3), touched, points[:3], []int{3})
+	if want := []float64{5, 5, 5}; !reflect.DeepEqual(dx, want) {
+		t.Errorf("interpolate() dx = %v, want %v", dx, want)
+	}
+}
+
+func TestRenderBannerVariation(t *testing.T) {
+	render := func(v Variation) string {
+		t.Helper()
+		b := Banner{Message: "Hi", Width: 40, Height: 6, Options: BannerOptions{Font: variableFont, Variation: v}}
+		result, err := Render(b)
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		return result.String()
+	}
+	light, bold := render(Variation{AxisWeight: 200}), render(Variation{AxisWeight: 900})
+	if strings.Count(bold, "@") <= strings.Count(light, "@") {
+		t.Errorf("Bold banner is not heavier:\n%s\n%s", light, bold)
+	}
+	if render(nil) != light {
+		t.Error("Expected the default weight without a variation")
+	}
+
+	// Fonts without the axis ignore it when used as a fallback
+	b := Banner{Message: "Hi Ж", Width: 40, Height: 6, Options: BannerOptions{
+		Font:      variableFont,
+		Fallback:  []Font{"Notable-Regular"},
+		Variation: Variation{AxisWeight: 900},
+	}}
+	if _, err := Render(b); err != nil {
+		t.Errorf("Render() with a static fallback error = %v", err)
+	}
+}
+
+func TestAddMalformedVariations(t *testing.T) {
+	data, err := embeddedFonts.ReadFile("fonts/" + string(variableFont) + ".ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append([]byte(nil), data...)
+	tables, err := sfntTables(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An axis count that disagrees with fvar makes gvar unreadable
+	tables["gvar"][5] = 7
+
+	r := &FontRegistry{fonts: make(map[Font]*fontEntry)}
+	if err := r.Add("Broken", data, "test"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if info := r.Fonts()[0]; len(info.Axes) != 0 {
+		t.Errorf("Axes = %+v, want none", info.Axes)
+	}
+	if err := r.ValidateVariation("Broken", Variation{AxisWeight: 700}); err == nil {
+		t.Error("Expected error for a variation of a font whose variations were ignored")
+	}
+	face, err := r.Face("Broken", 20)
+	if err != nil {
+		t.Fatalf("Face() error = %v", err)
+	}
+	face.Close()
+}
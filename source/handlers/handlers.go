@@ -2,29 +2,61 @@ package handlers
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
-	"html"
 	"html/template"
+	"image/png"
 	"io"
-	"log"
+	"log/slog"
+	"math"
 	"mime/multipart"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/MhunterDev/img2ascii/source/ansi"
 	"github.com/MhunterDev/img2ascii/source/banners"
 	"github.com/MhunterDev/img2ascii/source/img2ascii"
+	"github.com/MhunterDev/img2ascii/source/middleware"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"golang.org/x/image/font"
+	"golang.org/x/text/unicode/norm"
 )
 
 type Config struct {
 	OutputDir     string
 	MaxUploadSize int64
-	MaxBannerLen  int
+	MaxBannerLen  int              // in characters, counting combining marks with their base; text is also capped at 4 runes per character
+	ImageLimits   img2ascii.Limits // zero value means img2ascii.DefaultLimits
 	GlobalTmpl    *template.Template
+	Logger        *slog.Logger          // nil means slog.Default()
+	Fonts         *banners.FontRegistry // nil means banners.DefaultFonts()
+	FallbackFonts []banners.Font        // TrueType fonts for characters a banner's font lacks
+}
+
+func (cfg *Config) fonts() *banners.FontRegistry {
+	if cfg.Fonts == nil {
+		return banners.DefaultFonts()
+	}
+	return cfg.Fonts
+}
+
+// logger returns the configured logger annotated with the request ID
+func (cfg *Config) logger(c *gin.Context) *slog.Logger {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if id := middleware.GetRequestID(c); id != "" {
+		logger = logger.With("request_id", id)
+	}
+	return logger
 }
 
 func AllowedFileType(header *multipart.FileHeader) bool {
@@ -86,30 +118,46 @@ func HandleHome(cfg *Config) gin.HandlerFunc {
 
 func HandleUpload(cfg *Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		logger := cfg.logger(c)
 		upFile, err := c.FormFile("file")
 		if err != nil {
-			log.Printf("File upload error: %v", err)
+			logger.Warn("file upload failed", "err", err)
 			c.String(400, "File upload failed")
 			return
 		}
 
 		if upFile.Size > cfg.MaxUploadSize {
-			log.Printf("File too large: %d bytes (max: %d)", upFile.Size, cfg.MaxUploadSize)
+			logger.Warn("file too large", "size", upFile.Size, "max_size", cfg.MaxUploadSize)
 			c.String(400, "File too large")
 			return
 		}
 
 		if !AllowedFileType(upFile) {
-			log.Printf("Unsupported file type: %s", upFile.Header.Get("Content-Type"))
+			logger.Warn("unsupported file type", "content_type", upFile.Header.Get("Content-Type"))
 			c.String(400, "Unsupported file type")
 			return
 		}
 
+		// Response format: plain text (default), structured JSON or any
+		// other registered renderer such as sixel or irc
+		format := c.DefaultPostForm("format", "text")
+		renderer, err := img2ascii.LookupFormat(format)
+		if err != nil {
+			c.String(400, "Unsupported output format")
+			return
+		}
+		if ans, ok := renderer.(img2ascii.ANSI); ok {
+			ans.Title = sauceField(c.PostForm("title"), 35)
+			ans.Author = sauceField(c.PostForm("author"), 20)
+			ans.Group = sauceField(c.PostForm("group"), 20)
+			renderer = ans
+		}
+
 		// Create safe temporary file with sanitized name
 		safeFilename := sanitizeFilename(upFile.Filename)
 		tmpFile, err := os.CreateTemp("/tmp", "img2ascii_"+safeFilename+"_*.tmp")
 		if err != nil {
-			log.Printf("Failed to create temp file: %v", err)
+			logger.Error("failed to create temp file", "err", err)
 			c.String(500, "Internal server error")
 			return
 		}
@@ -117,13 +165,13 @@ func HandleUpload(cfg *Config) gin.HandlerFunc {
 		defer func() {
 			tmpFile.Close()
 			if err := os.Remove(tmpFile.Name()); err != nil {
-				log.Printf("Failed to remove temp file: %v", err)
+				logger.Error("failed to remove temp file", "err", err)
 			}
 		}()
 
 		file, err := upFile.Open()
 		if err != nil {
-			log.Printf("Failed to open uploaded file: %v", err)
+			logger.Error("failed to open uploaded file", "err", err)
 			c.String(500, "Internal server error")
 			return
 		}
@@ -132,150 +180,509 @@ func HandleUpload(cfg *Config) gin.HandlerFunc {
 		// Limit the amount of data we'll copy to prevent DoS
 		limitedReader := io.LimitReader(file, cfg.MaxUploadSize)
 		if _, err := io.Copy(tmpFile, limitedReader); err != nil {
-			log.Printf("Failed to save uploaded file: %v", err)
+			logger.Error("failed to save uploaded file", "err", err)
 			c.String(500, "Internal server error")
 			return
 		}
 
 		// Ensure data is written to disk
 		if err := tmpFile.Sync(); err != nil {
-			log.Printf("Failed to sync temp file: %v", err)
+			logger.Error("failed to sync temp file", "err", err)
 			c.String(500, "Internal server error")
 			return
 		}
 
-		outputID := uuid.New().String()
-		outputPath := filepath.Join(cfg.OutputDir, fmt.Sprintf("output-%s.txt", outputID))
-
 		// Parse aspect ratio options
 		aspectMode := c.PostForm("aspectMode")
 		options := img2ascii.ConversionOptions{
-			AspectMode: img2ascii.AspectScale, // default
-			Reverse:    true,
-			Mode:       img2ascii.ModeDefault,
+			AspectMode:       img2ascii.AspectScale, // default
+			TargetBackground: img2ascii.BackgroundDark,
+			Mode:             img2ascii.ModeDefault,
+			Limits:           cfg.ImageLimits,
+			Logger:           logger,
+
+			IncludeColors:    format == "json",
+			IncludeLuminance: format == "json",
 		}
 
+		if err := parseMappingOptions(c, &options); err != nil {
+			c.String(400, "Invalid options: %v", err)
+			return
+		}
+
+		filters, err := parseFilters(c)
+		if err != nil {
+			c.String(400, "Invalid options: %v", err)
+			return
+		}
+		options.Filters = filters
+
 		switch aspectMode {
 		case "pixel":
 			options.AspectMode = img2ascii.AspectPixel
 		case "fixed":
-			// Parse width and height for fixed size
-			if widthStr := c.PostForm("outputWidth"); widthStr != "" {
-				if width := parseIntDefault(widthStr, 80); width > 0 && width <= 200 {
-					options.FixedWidth = width
-				} else {
-					options.FixedWidth = 80
-				}
-			} else {
-				options.FixedWidth = 80
+			options.FixedWidth, options.FixedHeight = parseOutputSize(c)
+			options.AspectMode = img2ascii.AspectFixed
+		case "contain", "cover":
+			fit, _ := img2ascii.ParseFit(aspectMode)
+			width, height := parseOutputSize(c)
+			options.Layout = img2ascii.Layout{Fit: fit, Width: width, Height: height}
+		case "width":
+			width, _ := parseOutputSize(c)
+			options.Layout = img2ascii.Layout{Fit: img2ascii.FitWidth, Width: width, Height: maxOutputHeight}
+		case "height":
+			_, height := parseOutputSize(c)
+			options.Layout = img2ascii.Layout{Fit: img2ascii.FitHeight, Width: maxOutputWidth, Height: height}
+		case "budget":
+			budget := parseIntDefault(c.PostForm("budget"), defaultBudget)
+			if budget <= 0 || budget > maxOutputWidth*maxOutputHeight {
+				budget = defaultBudget
 			}
-
-			if heightStr := c.PostForm("outputHeight"); heightStr != "" {
-				if height := parseIntDefault(heightStr, 40); height > 0 && height <= 100 {
-					options.FixedHeight = height
-				} else {
-					options.FixedHeight = 40
-				}
-			} else {
-				options.FixedHeight = 40
+			options.Layout = img2ascii.Layout{
+				Fit:    img2ascii.FitBudget,
+				Width:  maxOutputWidth,
+				Height: maxOutputHeight,
+				Budget: budget,
 			}
-			options.AspectMode = img2ascii.AspectFixed
 		default: // "scale" or empty
 			options.AspectMode = img2ascii.AspectScale
 		}
 
-		runErr := img2ascii.RunWithOptions(tmpFile.Name(), outputPath, options)
+		art, runErr := img2ascii.Convert(tmpFile.Name(), options)
+		if img2ascii.IsImageTooLarge(runErr) {
+			logger.Warn("image rejected", "err", runErr)
+			c.String(413, "Image dimensions too large")
+			return
+		}
 		if runErr != nil {
-			log.Printf("ASCII conversion error: %v", runErr)
+			logger.Error("ascii conversion failed", "err", runErr)
 			c.String(500, "Conversion failed")
 			return
 		}
 
-		defer func() {
-			if err := os.Remove(outputPath); err != nil {
-				log.Printf("Failed to remove output file: %v", err)
-			}
-		}()
-
-		if _, err := os.Stat(outputPath); err != nil {
-			log.Printf("Output file not found: %v", err)
+		var out bytes.Buffer
+		err = renderer.Render(&out, art)
+		switch {
+		case errors.Is(err, img2ascii.ErrTooWide):
+			logger.Warn("art too wide for format", "format", format, "err", err)
+			c.String(422, "Output too wide for format; choose a smaller width")
+			return
+		case errors.Is(err, img2ascii.ErrOutputTooLarge):
+			logger.Warn("template output too large", "format", format)
+			c.String(422, "Output too large for format; choose a smaller size")
+			return
+		case err != nil:
+			logger.Error("rendering failed", "format", format, "err", err)
 			c.String(500, "Conversion failed")
 			return
 		}
+		c.Data(200, renderer.ContentType(), out.Bytes())
+	}
+}
+
+// Limits for rendering uploaded ANSI art
+const (
+	maxANSIRows  = 500
+	ansiFont     = banners.Font("SourceCodePro-Regular")
+	ansiFontSize = 13
 
-		c.File(outputPath)
+	// maxANSIPixels bounds PNG output, 32 MiB as RGBA. It fits 80 columns
+	// at the full row limit; wider art must be rendered as HTML.
+	maxANSIPixels = 8 << 20
+)
+
+// HandleANSI renders an uploaded .ans file or ANSI stream to PNG (default)
+// or HTML
+func HandleANSI(cfg *Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := cfg.logger(c)
+		upFile, err := c.FormFile("file")
+		if err != nil {
+			logger.Warn("file upload failed", "err", err)
+			c.String(400, "File upload failed")
+			return
+		}
+		if upFile.Size > cfg.MaxUploadSize {
+			logger.Warn("file too large", "size", upFile.Size, "max_size", cfg.MaxUploadSize)
+			c.String(400, "File too large")
+			return
+		}
+		format := c.DefaultPostForm("format", "png")
+		if format != "png" && format != "html" {
+			c.String(400, "Unsupported output format")
+			return
+		}
+
+		f, err := upFile.Open()
+		if err != nil {
+			logger.Error("failed to open upload", "err", err)
+			c.String(500, "Failed to read file")
+			return
+		}
+		defer f.Close()
+		data, err := io.ReadAll(io.LimitReader(f, cfg.MaxUploadSize))
+		if err != nil {
+			logger.Error("failed to read upload", "err", err)
+			c.String(500, "Failed to read file")
+			return
+		}
+
+		start := time.Now()
+		screen, err := ansi.Parse(data, ansi.ParseOptions{MaxRows: maxANSIRows})
+		if err != nil {
+			logger.Warn("invalid ANSI file", "err", err)
+			c.String(400, "Invalid ANSI file")
+			return
+		}
+
+		var out bytes.Buffer
+		contentType := "text/html; charset=utf-8"
+		if format == "html" {
+			err = screen.WriteHTML(&out)
+		} else {
+			if b := screen.Bounds(); b.Dx()*b.Dy() > maxANSIPixels {
+				logger.Warn("ANSI image too large", "width", b.Dx(), "height", b.Dy())
+				c.String(422, "Image too large; use format=html")
+				return
+			}
+			var face font.Face
+			face, err = cfg.fonts().Face(ansiFont, ansiFontSize)
+			if err != nil {
+				logger.Error("failed to load font", "font", string(ansiFont), "err", err)
+				c.String(500, "Rendering failed")
+				return
+			}
+			err = png.Encode(&out, screen.Image(face))
+			face.Close()
+			contentType = "image/png"
+		}
+		if err != nil {
+			logger.Error("ANSI rendering failed", "format", format, "err", err)
+			c.String(500, "Rendering failed")
+			return
+		}
+
+		logger.Info("ansi rendered",
+			"width", screen.Width,
+			"height", screen.Height,
+			"format", format,
+			"sauce", screen.Sauce != nil,
+			"truncated", screen.Truncated,
+			"duration", time.Since(start),
+		)
+		c.Data(200, contentType, out.Bytes())
 	}
 }
 
 func HandleBanner(cfg *Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		logger := cfg.logger(c)
 		bannerText := c.PostForm("bannerText")
 		if bannerText == "" {
 			c.String(400, "No banner text provided")
 			return
 		}
 
+		// Combining marks do not count towards MaxBannerLen, so cap the
+		// runes as well to bound the work of rendering stacked marks
+		maxRunes := maxRunesPerChar * cfg.MaxBannerLen
+		if len(bannerText) > utf8.UTFMax*maxRunes {
+			c.String(400, "Banner text too long")
+			return
+		}
+
 		// Sanitize and validate input
 		cleanText, err := sanitizeBannerText(bannerText)
 		if err != nil {
-			log.Printf("Invalid banner text: %v", err)
+			logger.Warn("invalid banner text", "err", err)
 			c.String(400, "Invalid banner text")
 			return
 		}
 
-		if len(cleanText) > cfg.MaxBannerLen {
+		if graphemeCount(cleanText) > cfg.MaxBannerLen || utf8.RuneCountInString(cleanText) > maxRunes {
 			c.String(400, "Banner text too long")
 			return
 		}
 
-		outputID := uuid.New().String()
-		outputPath := filepath.Join(cfg.OutputDir, fmt.Sprintf("banner-%s", outputID))
+		fontName := banners.Font(c.DefaultPostForm("font", string(banners.DefaultFont)))
+		if !cfg.fonts().Has(fontName) {
+			c.String(400, "Unknown font")
+			return
+		}
+
+		layout, err := banners.ParseLayoutMode(c.DefaultPostForm("layout", "default"))
+		if err != nil {
+			c.String(400, "Invalid options: %v", err)
+			return
+		}
+		style, err := banners.ParseBannerStyle(c.DefaultPostForm("style", "plain"))
+		if err != nil {
+			c.String(400, "Invalid options: %v", err)
+			return
+		}
+		align, err := banners.ParseAlignment(c.DefaultPostForm("align", "center"))
+		if err != nil {
+			c.String(400, "Invalid options: %v", err)
+			return
+		}
+		height, err := formInt(c, "height", minBannerHeight, maxBannerHeight)
+		if err != nil {
+			c.String(400, "Invalid options: %v", err)
+			return
+		}
+		maxWidth, err := formInt(c, "maxWidth", minBannerWidth, maxBannerWidth)
+		if err != nil {
+			c.String(400, "Invalid options: %v", err)
+			return
+		}
+		characters := c.PostForm("characters")
+		if characters != "" {
+			if err := validateRamp(characters); err != nil {
+				c.String(400, "Invalid options: %v", err)
+				return
+			}
+		}
+		reverse := c.PostForm("reverse") == "true"
+		if _, err := cfg.fonts().FIGlet(fontName); err == nil && (characters != "" || reverse) {
+			c.String(400, "Invalid options: characters and reverse are only available for TrueType fonts")
+			return
+		}
+		variation, err := formVariation(c)
+		if err == nil {
+			err = cfg.fonts().ValidateVariation(fontName, variation)
+		}
+		if err != nil {
+			c.String(400, "Invalid options: %v", err)
+			return
+		}
+		lineSpacing := 1.0
+		if s := c.PostForm("lineSpacing"); s != "" {
+			lineSpacing, err = strconv.ParseFloat(s, 64)
+			if err != nil || math.IsNaN(lineSpacing) || math.IsInf(lineSpacing, 0) || lineSpacing < minLineSpacing || lineSpacing > maxLineSpacing {
+				c.String(400, "Invalid options: line spacing must be between %g and %g", minLineSpacing, maxLineSpacing)
+				return
+			}
+		}
+
+		format := c.DefaultPostForm("format", "text")
+		if format != "text" && format != "png" {
+			c.String(400, "Unsupported output format")
+			return
+		}
 
 		banner := banners.Banner{
 			Message: cleanText,
-			Path:    outputPath,
-			Width:   50,
-			Height:  15,
+			Width:   maxWidth,
+			Height:  height,
 			Options: banners.BannerOptions{
-				Font:    "Notable-Regular",
-				Reverse: true,
+				Font:        fontName,
+				Reverse:     reverse,
+				Characters:  characters,
+				SelfFill:    c.PostForm("selfFill") == "true",
+				Style:       style,
+				Layout:      layout,
+				Align:       align,
+				LineSpacing: lineSpacing,
+				Fallback:    cfg.FallbackFonts,
+				Variation:   variation,
 			},
+			Fonts: cfg.fonts(),
 		}
 
-		if err := banners.RenderBanner(banner); err != nil {
-			log.Printf("Banner generation error: %v", err)
-			c.String(500, "Banner generation failed")
+		missing, err := banner.MissingRunes()
+		if err != nil {
+			c.String(400, "Invalid options: %v", err)
+			return
+		}
+		if len(missing) > 0 {
+			c.String(400, "No font can draw these characters: %s", string(missing))
 			return
 		}
 
-		asciiPath := outputPath + ".txt"
-		data, err := os.ReadFile(asciiPath)
+		start := time.Now()
+		result, err := banners.Render(banner)
+		if errors.Is(err, banners.ErrBannerTooLarge) {
+			c.String(400, "Banner too large; use fewer lines or a smaller height")
+			return
+		}
 		if err != nil {
-			log.Printf("Failed to read ASCII output: %v", err)
-			c.String(500, "Failed to read banner output")
+			logger.Error("banner generation failed", "err", err)
+			c.String(500, "Banner generation failed")
 			return
 		}
 
-		defer func() {
-			if err := os.Remove(asciiPath); err != nil {
-				log.Printf("Failed to remove banner ascii file: %v", err)
+		logger.Info("banner rendered",
+			"rows", len(result.Rows),
+			"font", string(banner.Options.Font),
+			"format", format,
+			"length", graphemeCount(cleanText),
+			"duration", time.Since(start),
+		)
+
+		if format == "png" {
+			var buf bytes.Buffer
+			if err := result.WritePNG(&buf); errors.Is(err, banners.ErrNoImage) {
+				c.String(400, "PNG output is only available for TrueType fonts")
+				return
+			} else if err != nil {
+				logger.Error("failed to encode banner image", "err", err)
+				c.String(500, "Banner generation failed")
+				return
 			}
-		}()
+			c.Data(200, "image/png", buf.Bytes())
+			return
+		}
+		c.Data(200, "text/plain; charset=utf-8", []byte(result.String()))
+	}
+}
 
-		c.Data(200, "text/plain; charset=utf-8", data)
+// HandleFonts lists the fonts available to /banner
+func HandleFonts(cfg *Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"default": banners.DefaultFont,
+			"fonts":   cfg.fonts().Fonts(),
+		})
+	}
+}
+
+// Limits on the height of fonts converted by HandleFontFIGlet
+const (
+	minFIGletHeight = 3
+	maxFIGletHeight = 32
+)
+
+// HandleFontFIGlet converts a TrueType font to a FIGlet (.flf) font for use
+// with figlet and toilet. The optional height query parameter sets the rows
+// per character.
+func HandleFontFIGlet(cfg *Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := banners.Font(c.Param("name"))
+		height := 0
+		if s := c.Query("height"); s != "" {
+			h, err := strconv.Atoi(s)
+			if err != nil || h < minFIGletHeight || h > maxFIGletHeight {
+				c.String(400, "Height must be between %d and %d", minFIGletHeight, maxFIGletHeight)
+				return
+			}
+			height = h
+		}
+		if !cfg.fonts().Has(name) {
+			c.String(404, "Unknown font")
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := cfg.fonts().WriteFIGlet(&buf, name, banners.FIGletOptions{Height: height}); err != nil {
+			c.String(400, "Cannot convert font: %v", err)
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.flf"`, name))
+		c.Data(200, "text/plain; charset=utf-8", buf.Bytes())
 	}
 }
 
 // Input validation and sanitization helpers
-var (
-	// Allow alphanumeric, spaces, basic punctuation for banner text
-	allowedBannerChars = regexp.MustCompile(`^[a-zA-Z0-9\s\.,!?\-_]+$`)
+
+// allowedBannerRune reports whether r may appear in banner text: letters,
+// combining marks, numbers, punctuation, mathematical, currency and
+// modifier symbols, spaces and line breaks. Control and format characters,
+// such as bidirectional overrides, are rejected.
+func allowedBannerRune(r rune) bool {
+	return r == ' ' || r == '\n' ||
+		unicode.In(r, unicode.L, unicode.M, unicode.N, unicode.P, unicode.Sm, unicode.Sc, unicode.Sk)
+}
+
+// maxRunesPerChar limits banner text to this many runes per allowed
+// character, leaving room for accents while bounding long runs of marks
+const maxRunesPerChar = 4
+
+// graphemeCount counts user-perceived characters the way the art is split
+// into cells, so a letter and its accents or a flag count as one
+func graphemeCount(s string) int {
+	return len(img2ascii.SplitCells(s))
+}
+
+// Limits on the banner options. Height is the rows per line of TrueType
+// fonts and maxWidth the columns banners wrap at.
+const (
+	minBannerHeight = 2
+	maxBannerHeight = 32
+	minBannerWidth  = 10
+	maxBannerWidth  = 200
+	minLineSpacing  = 0.5
+	maxLineSpacing  = 3
 )
 
-// sanitizeBannerText cleans and validates banner text input
+// formInt reads an optional integer form field, returning 0 when it is
+// absent. Errors are safe to echo back to the client.
+func formInt(c *gin.Context, name string, lo, hi int) (int, error) {
+	s := c.PostForm(name)
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < lo || n > hi {
+		return 0, fmt.Errorf("%s must be between %d and %d", name, lo, hi)
+	}
+	return n, nil
+}
+
+// variationFields maps banner form fields to the variable font axes they
+// set
+var variationFields = []struct{ field, axis string }{
+	{"fontWeight", banners.AxisWeight},
+	{"fontWidth", banners.AxisWidth},
+}
+
+// formVariation reads the optional variable font axis fields, returning
+// nil when none is given. Errors are safe to echo back to the client.
+func formVariation(c *gin.Context) (banners.Variation, error) {
+	var v banners.Variation
+	for _, f := range variationFields {
+		s := c.PostForm(f.field)
+		if s == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil, fmt.Errorf("%s must be a number", f.field)
+		}
+		if v == nil {
+			v = banners.Variation{}
+		}
+		v[f.axis] = value
+	}
+	return v, nil
+}
+
+// maxBannerLines limits the explicit lines in banner text
+const maxBannerLines = 10
+
+// sanitizeBannerText cleans and validates banner text input. Text is
+// normalized to NFC so accented letters use the fonts' precomposed glyphs,
+// line breaks are kept as "\n" and other whitespace becomes spaces. The
+// text is drawn, not served as markup, so it is not escaped.
 func sanitizeBannerText(input string) (string, error) {
-	// Trim whitespace
-	cleaned := strings.TrimSpace(input)
+	if !utf8.ValidString(input) {
+		return "", fmt.Errorf("banner text must be valid UTF-8")
+	}
+
+	// Normalize line endings and whitespace, then trim
+	input = norm.NFC.String(input)
+	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRightFunc(strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return ' '
+			}
+			return r
+		}, line), unicode.IsSpace)
+	}
+	cleaned := strings.Trim(strings.Join(lines, "\n"), "\n ")
+	if strings.Count(cleaned, "\n") >= maxBannerLines {
+		return "", fmt.Errorf("banner text has more than %d lines", maxBannerLines)
+	}
 
 	// Check length
 	if len(cleaned) == 0 {
@@ -283,13 +690,10 @@ func sanitizeBannerText(input string) (string, error) {
 	}
 
 	// Check for allowed characters
-	if !allowedBannerChars.MatchString(cleaned) {
+	if strings.IndexFunc(cleaned, func(r rune) bool { return !allowedBannerRune(r) }) >= 0 {
 		return "", fmt.Errorf("banner text contains invalid characters")
 	}
 
-	// HTML escape for additional safety
-	cleaned = html.EscapeString(cleaned)
-
 	return cleaned, nil
 }
 
@@ -319,6 +723,178 @@ func sanitizeFilename(filename string) string {
 	return safe
 }
 
+const (
+	maxRampLen     = 64
+	maxLevels      = 16
+	maxFilters     = 8
+	maxFillTextLen = 256
+)
+
+// parseMappingOptions reads the form fields that control how luminance is
+// mapped to characters. Errors are safe to echo back to the client.
+func parseMappingOptions(c *gin.Context, options *img2ascii.ConversionOptions) error {
+	if name := c.PostForm("luminance"); name != "" {
+		model, err := img2ascii.ParseLuminanceModel(name)
+		if err != nil {
+			return fmt.Errorf("unsupported luminance model")
+		}
+		options.Luminance = model
+	}
+
+	// "unset" defers to Reverse, which the web API does not expose
+	if name := c.PostForm("background"); name != "" {
+		bg, err := img2ascii.ParseBackground(name)
+		if err != nil || bg == img2ascii.BackgroundUnset {
+			return fmt.Errorf("unsupported background")
+		}
+		options.TargetBackground = bg
+	}
+
+	if name := c.PostForm("mode"); name != "" {
+		mode, err := img2ascii.ParseConversionMode(name)
+		if err != nil {
+			return fmt.Errorf("unsupported conversion mode")
+		}
+		options.Mode = mode
+	}
+
+	// Empty or "auto" keeps Otsu's automatic threshold
+	if s := c.PostForm("threshold"); s != "" && s != "auto" {
+		threshold, err := strconv.Atoi(s)
+		if err != nil || threshold < 1 || threshold > 255 {
+			return fmt.Errorf("threshold must be between 1 and 255")
+		}
+		options.Threshold = threshold
+	}
+
+	if s := c.PostForm("levels"); s != "" {
+		levels, err := strconv.Atoi(s)
+		if err != nil || levels < 2 || levels > maxLevels {
+			return fmt.Errorf("levels must be between 2 and %d", maxLevels)
+		}
+		options.Levels = levels
+	}
+
+	// Spaces are meaningful in a ramp, so the value is not trimmed
+	if chars := c.PostForm("characters"); chars != "" {
+		if err := validateRamp(chars); err != nil {
+			return err
+		}
+		options.Characters = chars
+	}
+
+	if text := c.PostForm("fillText"); text != "" {
+		if err := validateFillText(text); err != nil {
+			return err
+		}
+		options.FillText = text
+	}
+	options.FillAll = c.PostForm("fillAll") == "true"
+
+	if name := c.PostForm("palette"); name != "" {
+		palette, err := img2ascii.ParseEmojiPalette(name)
+		if err != nil {
+			return fmt.Errorf("unsupported emoji palette")
+		}
+		options.Palette = palette
+	}
+	return nil
+}
+
+// parseFilters builds the preprocessing filters named in the "filters" form
+// field, which may be repeated or hold a comma-separated list, in order
+func parseFilters(c *gin.Context) ([]img2ascii.Filter, error) {
+	var filters []img2ascii.Filter
+	for _, value := range c.PostFormArray("filters") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if len(filters) == maxFilters {
+				return nil, fmt.Errorf("at most %d filters are allowed", maxFilters)
+			}
+			f, err := img2ascii.NewFilter(name)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, f)
+		}
+	}
+	return filters, nil
+}
+
+// validateRamp checks a user-supplied character ramp
+func validateRamp(chars string) error {
+	if !utf8.ValidString(chars) {
+		return fmt.Errorf("characters must be valid UTF-8")
+	}
+	n := utf8.RuneCountInString(chars)
+	if n < 2 || n > maxRampLen {
+		return fmt.Errorf("characters must contain between 2 and %d characters", maxRampLen)
+	}
+	for _, r := range chars {
+		if !unicode.IsGraphic(r) {
+			return fmt.Errorf("characters contains a non-printable character")
+		}
+	}
+	return nil
+}
+
+// validateFillText checks the phrase for text-fill mode. Whitespace is
+// allowed since the converter collapses it to single spaces.
+func validateFillText(text string) error {
+	if !utf8.ValidString(text) {
+		return fmt.Errorf("fill text must be valid UTF-8")
+	}
+	if utf8.RuneCountInString(text) > maxFillTextLen {
+		return fmt.Errorf("fill text must be at most %d characters", maxFillTextLen)
+	}
+	for _, r := range text {
+		if !unicode.IsGraphic(r) && !unicode.IsSpace(r) {
+			return fmt.Errorf("fill text contains a non-printable character")
+		}
+	}
+	return nil
+}
+
+// sauceField keeps the printable characters of a SAUCE text field, up to n
+func sauceField(s string, n int) string {
+	var out []rune
+	for _, r := range strings.ToValidUTF8(s, "") {
+		if len(out) == n {
+			break
+		}
+		if unicode.IsPrint(r) {
+			out = append(out, r)
+		}
+	}
+	return string(out)
+}
+
+// Output grid bounds accepted from the upload form
+const (
+	defaultOutputWidth  = 80
+	defaultOutputHeight = 40
+	maxOutputWidth      = 200
+	maxOutputHeight     = 100
+	defaultBudget       = 4000
+)
+
+// parseOutputSize reads outputWidth and outputHeight, falling back to the
+// defaults for missing or out-of-range values
+func parseOutputSize(c *gin.Context) (width, height int) {
+	width = parseIntDefault(c.PostForm("outputWidth"), defaultOutputWidth)
+	if width <= 0 || width > maxOutputWidth {
+		width = defaultOutputWidth
+	}
+	height = parseIntDefault(c.PostForm("outputHeight"), defaultOutputHeight)
+	if height <= 0 || height > maxOutputHeight {
+		height = defaultOutputHeight
+	}
+	return width, height
+}
+
 // parseIntDefault parses a string to int with a default fallback
 func parseIntDefault(s string, defaultVal int) int {
 	if val, err := strconv.Atoi(s); err == nil {
@@ -2,10 +2,24 @@ package handlers
 
 import (
 	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
 	"mime/multipart"
+	"net/http"
 	"net/http/httptest"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strings"
 	"testing"
 
+	"github.com/MhunterDev/img2ascii/source/ansi"
+	"github.com/MhunterDev/img2ascii/source/banners"
+	"github.com/MhunterDev/img2ascii/source/img2ascii"
 	"github.com/gin-gonic/gin"
 )
 
@@ -136,16 +150,58 @@ func TestSanitizeBannerText(t *testing.T) {
 		},
 		{
 			name:     "Text with invalid characters",
-			input:    "Hello<script>",
+			input:    "Hello\u202eWorld",
 			expected: "",
 			hasError: true,
 		},
+		{
+			name:     "Control character",
+			input:    "Hello\x07",
+			expected: "",
+			hasError: true,
+		},
+		{
+			name:     "Emoji",
+			input:    "Hi 🎉",
+			expected: "",
+			hasError: true,
+		},
+		{
+			name:     "Accents, Cyrillic and CJK",
+			input:    "Zoë Жанна 漢字",
+			expected: "Zoë Жанна 漢字",
+			hasError: false,
+		},
+		{
+			name:     "Combining marks normalized",
+			input:    "Zoe\u0308",
+			expected: "Zoë",
+			hasError: false,
+		},
+		{
+			name:     "Not HTML escaped",
+			input:    "Tom & Jerry's <3",
+			expected: "Tom & Jerry's <3",
+			hasError: false,
+		},
 		{
 			name:     "Text with question mark",
 			input:    "Hello World?",
 			expected: "Hello World?",
 			hasError: false,
 		},
+		{
+			name:     "Line breaks kept",
+			input:    "  Hello \r\n\tWorld \n\n",
+			expected: "Hello\n World",
+			hasError: false,
+		},
+		{
+			name:     "Too many lines",
+			input:    strings.Repeat("a\n", maxBannerLines) + "a",
+			expected: "",
+			hasError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -168,6 +224,26 @@ func TestSanitizeBannerText(t *testing.T) {
 	}
 }
 
+func TestGraphemeCount(t *testing.T) {
+	tests := map[string]int{
+		"":               0,
+		"Hello":          5,
+		"Zoe\u0308":      3,
+		"Жанна":          5,
+		"漢字":             2,
+		"a\u0301\u0323b": 2,
+		"\U0001f1eb\U0001f1f7\U0001f1e9\U0001f1ea": 2, // two flags
+		"\U0001f1eb\U0001f1f7\U0001f1e9":           2,
+		"\U0001f44d\U0001f3fd!":                    2,
+		"\u2764\ufe0f":                             1,
+	}
+	for s, want := range tests {
+		if got := graphemeCount(s); got != want {
+			t.Errorf("graphemeCount(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
 func TestSanitizeFilename(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -214,9 +290,565 @@ func TestSanitizeFilename(t *testing.T) {
 	}
 }
 
+func TestHandleUploadRejectsHugeDimensions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// A 1x1 PNG whose IHDR claims 30000x30000 pixels.
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	data := img.Bytes()
+	ihdr := data[12 : 12+4+13]
+	binary.BigEndian.PutUint32(ihdr[4:8], 30000)
+	binary.BigEndian.PutUint32(ihdr[8:12], 30000)
+	binary.BigEndian.PutUint32(data[12+4+13:], crc32.ChecksumIEEE(ihdr))
+
+	cfg := &Config{
+		OutputDir:     t.TempDir(),
+		MaxUploadSize: 2 << 20,
+	}
+	r := gin.New()
+	r.POST("/upload", HandleUpload(cfg))
+
+	req := newUploadRequest(t, data, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != 413 {
+		t.Errorf("Expected 413, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// newUploadRequest builds a multipart POST to /upload carrying data as a PNG
+// file plus the given form fields.
+func newUploadRequest(t *testing.T, data []byte, fields map[string]string) *http.Request {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="file"; filename="test.png"`)
+	h.Set("Content-Type", "image/png")
+	part, err := writer.CreatePart(h)
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	part.Write(data)
+	for k, v := range fields {
+		writer.WriteField(k, v)
+	}
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 255 / width), G: 64, B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestHandleUploadFormats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &Config{
+		OutputDir:     t.TempDir(),
+		MaxUploadSize: 2 << 20,
+	}
+	r := gin.New()
+	r.POST("/upload", HandleUpload(cfg))
+	data := encodeTestPNG(t, 16, 8)
+	fixed := map[string]string{"aspectMode": "fixed", "outputWidth": "16", "outputHeight": "8"}
+
+	t.Run("Text", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newUploadRequest(t, data, fixed))
+		if w.Code != 200 {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+		if len(lines) != 8 || len(lines[0]) != 16 {
+			t.Errorf("Expected 16x8 text art, got %d lines of %d", len(lines), len(lines[0]))
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		fields := map[string]string{"format": "json"}
+		for k, v := range fixed {
+			fields[k] = v
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newUploadRequest(t, data, fields))
+		if w.Code != 200 {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var art struct {
+			Width     int        `json:"width"`
+			Height    int        `json:"height"`
+			Rows      []string   `json:"rows"`
+			Colors    [][]string `json:"colors"`
+			Luminance [][]int    `json:"luminance"`
+			Source    struct {
+				Width  int `json:"width"`
+				Height int `json:"height"`
+			} `json:"source"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &art); err != nil {
+			t.Fatalf("Invalid JSON response: %v", err)
+		}
+		if art.Width != 16 || art.Height != 8 || len(art.Rows) != 8 {
+			t.Errorf("Unexpected art dimensions %dx%d with %d rows", art.Width, art.Height, len(art.Rows))
+		}
+		if len(art.Colors) != 8 || len(art.Colors[0]) != 16 || len(art.Luminance) != 8 {
+			t.Error("Expected per-cell colors and luminance in JSON response")
+		}
+		if art.Source.Width != 16 || art.Source.Height != 8 {
+			t.Errorf("Source = %+v, want 16x8", art.Source)
+		}
+	})
+
+	t.Run("Threshold mode", func(t *testing.T) {
+		fields := map[string]string{"mode": "threshold", "threshold": "128", "characters": "# "}
+		for k, v := range fixed {
+			fields[k] = v
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newUploadRequest(t, data, fields))
+		if w.Code != 200 {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if strings.Trim(w.Body.String(), "# \n") != "" {
+			t.Errorf("Threshold output should only use the two ramp characters, got %q", w.Body.String())
+		}
+	})
+
+	t.Run("Fit modes", func(t *testing.T) {
+		tests := []struct {
+			fields        map[string]string
+			width, height int
+		}{
+			{map[string]string{"aspectMode": "cover", "outputWidth": "10", "outputHeight": "10"}, 10, 10},
+			{map[string]string{"aspectMode": "contain", "outputWidth": "10", "outputHeight": "10"}, 10, 5},
+			{map[string]string{"aspectMode": "width", "outputWidth": "30"}, 30, 15},
+			{map[string]string{"aspectMode": "height", "outputHeight": "12"}, 24, 12},
+			{map[string]string{"aspectMode": "budget", "budget": "200"}, 20, 10},
+		}
+		for _, tt := range tests {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, newUploadRequest(t, data, tt.fields))
+			if w.Code != 200 {
+				t.Fatalf("%v: expected 200, got %d: %s", tt.fields, w.Code, w.Body.String())
+			}
+			lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+			if len(lines) != tt.height || len(lines[0]) != tt.width {
+				t.Errorf("%v: got %dx%d, want %dx%d", tt.fields, len(lines[0]), len(lines), tt.width, tt.height)
+			}
+		}
+	})
+
+	t.Run("Sixel", func(t *testing.T) {
+		fields := map[string]string{"format": "sixel"}
+		for k, v := range fixed {
+			fields[k] = v
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newUploadRequest(t, data, fields))
+		if w.Code != 200 {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "image/x-sixel" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		body := w.Body.String()
+		if !strings.HasPrefix(body, "\x1bP") || !strings.HasSuffix(body, "\x1b\\") {
+			t.Errorf("Response is not a DCS sixel stream: %q", body)
+		}
+	})
+
+	t.Run("ANSI", func(t *testing.T) {
+		fields := map[string]string{"format": "ans", "title": "Test\x1btitle", "author": "me"}
+		for k, v := range fixed {
+			fields[k] = v
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newUploadRequest(t, data, fields))
+		if w.Code != 200 {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		_, sauce, err := ansi.ParseSauce(w.Body.Bytes())
+		if err != nil || sauce == nil {
+			t.Fatalf("Response has no SAUCE record: %v", err)
+		}
+		if sauce.Title != "Testtitle" || sauce.Author != "me" || sauce.TInfo1 != 16 || sauce.TInfo2 != 8 {
+			t.Errorf("SAUCE = %+v", sauce)
+		}
+	})
+
+	t.Run("Chat formats", func(t *testing.T) {
+		tests := []struct {
+			format string
+			prefix string
+		}{
+			{"irc", "\x03"},
+			{"bbcode", "[color=#"},
+			{"markdown", "```text\n"},
+		}
+		for _, tt := range tests {
+			fields := map[string]string{"format": tt.format}
+			for k, v := range fixed {
+				fields[k] = v
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, newUploadRequest(t, data, fields))
+			if w.Code != 200 {
+				t.Fatalf("%s: expected 200, got %d: %s", tt.format, w.Code, w.Body.String())
+			}
+			if !strings.HasPrefix(w.Body.String(), tt.prefix) {
+				t.Errorf("%s: response = %q, want prefix %q", tt.format, w.Body.String(), tt.prefix)
+			}
+		}
+	})
+
+	t.Run("Markdown too wide", func(t *testing.T) {
+		fields := map[string]string{"format": "markdown", "aspectMode": "width", "outputWidth": "120"}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newUploadRequest(t, data, fields))
+		if w.Code != 422 {
+			t.Errorf("Expected 422, got %d", w.Code)
+		}
+	})
+
+	t.Run("Unsupported luminance", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newUploadRequest(t, data, map[string]string{"luminance": "hsv"}))
+		if w.Code != 400 {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newUploadRequest(t, data, map[string]string{"format": "xml"}))
+		if w.Code != 400 {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+	})
+}
+
+func TestParseMappingOptions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		fields   map[string]string
+		hasError bool
+	}{
+		{"Empty", map[string]string{}, false},
+		{"Posterize", map[string]string{"mode": "posterize", "levels": "6"}, false},
+		{"Auto threshold", map[string]string{"mode": "threshold", "threshold": "auto"}, false},
+		{"Unicode ramp", map[string]string{"characters": "█▓▒░ "}, false},
+		{"Auto background", map[string]string{"background": "auto"}, false},
+		{"Text fill", map[string]string{"mode": "textfill", "fillText": "Hello,\tWorld!", "fillAll": "true"}, false},
+		{"Emoji hearts", map[string]string{"mode": "emoji", "palette": "hearts"}, false},
+		{"Unknown emoji palette", map[string]string{"mode": "emoji", "palette": "flags"}, true},
+		{"Fill text too long", map[string]string{"fillText": strings.Repeat("a", 257)}, true},
+		{"Control character in fill text", map[string]string{"fillText": "a\x00b"}, true},
+		{"Unset background", map[string]string{"background": "unset"}, true},
+		{"Unknown background", map[string]string{"background": "grey"}, true},
+		{"Unknown mode", map[string]string{"mode": "sepia"}, true},
+		{"Threshold too high", map[string]string{"threshold": "256"}, true},
+		{"Threshold not a number", map[string]string{"threshold": "half"}, true},
+		{"Too few levels", map[string]string{"levels": "1"}, true},
+		{"Too many levels", map[string]string{"levels": "17"}, true},
+		{"Single character ramp", map[string]string{"characters": "#"}, true},
+		{"Control character in ramp", map[string]string{"characters": "#\n."}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			for k, v := range tt.fields {
+				form.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/upload", strings.NewReader(form.Encode()))
+			c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			var options img2ascii.ConversionOptions
+			err := parseMappingOptions(c, &options)
+			if (err != nil) != tt.hasError {
+				t.Errorf("parseMappingOptions() error = %v, hasError %v", err, tt.hasError)
+			}
+		})
+	}
+}
+
+func TestParseFilters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		values   []string
+		count    int
+		hasError bool
+	}{
+		{"None", nil, 0, false},
+		{"Repeated field", []string{"blur", "invert"}, 2, false},
+		{"Comma separated", []string{"desaturate, sharpen,,vignette"}, 3, false},
+		{"Unknown filter", []string{"blur,sepia"}, 0, true},
+		{"Too many", []string{"blur,blur,blur,blur,blur,blur,blur,blur,blur"}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"filters": tt.values}
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/upload", strings.NewReader(form.Encode()))
+			c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			filters, err := parseFilters(c)
+			if (err != nil) != tt.hasError {
+				t.Fatalf("parseFilters() error = %v, hasError %v", err, tt.hasError)
+			}
+			if len(filters) != tt.count {
+				t.Errorf("parseFilters() returned %d filters, want %d", len(filters), tt.count)
+			}
+		})
+	}
+}
+
 func TestHandleHome(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	// Skip this test since it requires a proper template
 	t.Skip("Requires proper template setup")
 }
+
+func TestHandleANSI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &Config{MaxUploadSize: 1 << 20}
+	r := gin.New()
+	r.POST("/ansi", HandleANSI(cfg))
+	data := []byte("\x1b[1;31m\xdb\xdb<script>\x1b[0m\r\nok\r\n")
+	ansiRequest := func(fields map[string]string) *http.Request {
+		req := newUploadRequest(t, data, fields)
+		req.URL.Path = "/ansi"
+		return req
+	}
+
+	t.Run("HTML", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, ansiRequest(map[string]string{"format": "html"}))
+		if w.Code != 200 {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		body := w.Body.String()
+		if !strings.Contains(body, "██&lt;script&gt;") || strings.Contains(body, "<script>") {
+			t.Errorf("Unexpected HTML: %s", body)
+		}
+	})
+
+	t.Run("PNG", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, ansiRequest(nil))
+		if w.Code != 200 {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		img, err := png.Decode(w.Body)
+		if err != nil {
+			t.Fatalf("Invalid PNG response: %v", err)
+		}
+		if b := img.Bounds(); b.Dx() != 80*8 || b.Dy() != 2*16 {
+			t.Errorf("Image is %dx%d, want 640x32", b.Dx(), b.Dy())
+		}
+	})
+
+	t.Run("PNG too large", func(t *testing.T) {
+		record, _ := ansi.Sauce{DataType: ansi.DataTypeCharacter, TInfo1: ansi.MaxColumns}.MarshalBinary()
+		wide := append(bytes.Repeat([]byte("x\r\n"), maxANSIRows), record...)
+		for _, tt := range []struct {
+			format string
+			want   int
+		}{{"png", 422}, {"html", 200}} {
+			req := newUploadRequest(t, wide, map[string]string{"format": tt.format})
+			req.URL.Path = "/ansi"
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("%s: expected %d, got %d", tt.format, tt.want, w.Code)
+			}
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, ansiRequest(map[string]string{"format": "gif"}))
+		if w.Code != 400 {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+	})
+}
+
+func TestHandleFonts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/fonts", HandleFonts(&Config{}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/fonts", nil))
+	if w.Code != 200 {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	var resp struct {
+		Default string             `json:"default"`
+		Fonts   []banners.FontInfo `json:"fonts"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Invalid JSON response: %v", err)
+	}
+	if resp.Default != "Notable-Regular" || len(resp.Fonts) != 5 {
+		t.Fatalf("Unexpected response: %s", w.Body.String())
+	}
+	if f := resp.Fonts[1]; f.Name != "Notable-Regular" || f.Family != "Notable" || f.Source != "embedded" || len(f.Axes) != 0 {
+		t.Errorf("Fonts[1] = %+v", f)
+	}
+	if axes := resp.Fonts[2].Axes; len(axes) != 1 || axes[0].Tag != "wght" || axes[0].Min != 200 || axes[0].Max != 900 {
+		t.Errorf("Fonts[2].Axes = %+v", axes)
+	}
+}
+
+func TestHandleFontFIGlet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/fonts/:name/flf", HandleFontFIGlet(&Config{}))
+
+	tests := []struct {
+		path string
+		code int
+	}{
+		{"/fonts/Cookie-Regular/flf?height=5", 200},
+		{"/fonts/Missing/flf", 404},
+		{"/fonts/standard/flf", 400},
+		{"/fonts/Cookie-Regular/flf?height=1", 400},
+		{"/fonts/Cookie-Regular/flf?height=abc", 400},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.code {
+			t.Errorf("%s: expected %d, got %d: %s", tt.path, tt.code, w.Code, w.Body.String())
+			continue
+		}
+		if tt.code != 200 {
+			continue
+		}
+		f, err := banners.ParseFIGlet(w.Body)
+		if err != nil {
+			t.Fatalf("Invalid FIGlet font: %v", err)
+		}
+		if f.Height != 5 {
+			t.Errorf("Height = %d, want 5", f.Height)
+		}
+		if got := w.Header().Get("Content-Disposition"); !strings.Contains(got, "Cookie-Regular.flf") {
+			t.Errorf("Content-Disposition = %q", got)
+		}
+	}
+}
+
+func TestHandleBanner(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &Config{OutputDir: t.TempDir(), MaxBannerLen: 64, FallbackFonts: []banners.Font{"SourceCodePro-Regular"}}
+	r := gin.New()
+	r.POST("/banner", HandleBanner(cfg))
+
+	bannerRequest := func(fields map[string]string) *http.Request {
+		form := url.Values{}
+		for k, v := range fields {
+			form.Set(k, v)
+		}
+		req := httptest.NewRequest("POST", "/banner", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req
+	}
+
+	tests := []struct {
+		name   string
+		fields map[string]string
+		code   int
+	}{
+		{"default font", map[string]string{"bannerText": "Hi"}, 200},
+		{"chosen font", map[string]string{"bannerText": "Hi", "font": "Cookie-Regular"}, 200},
+		{"figlet font", map[string]string{"bannerText": "Hi", "font": "standard", "layout": "fit"}, 200},
+		{"unknown font", map[string]string{"bannerText": "Hi", "font": "../../etc/passwd"}, 400},
+		{"unknown layout", map[string]string{"bannerText": "Hi", "font": "standard", "layout": "wide"}, 400},
+		{"unicode", map[string]string{"bannerText": "Zoë Жанна"}, 200},
+		{"characters no font has", map[string]string{"bannerText": "Hi 漢字"}, 400},
+		{"characters the figlet font lacks", map[string]string{"bannerText": "Жанна", "font": "standard"}, 400},
+		{"multi-line", map[string]string{"bannerText": "Hi\r\nthere", "align": "left", "lineSpacing": "1.5"}, 200},
+		{"unknown alignment", map[string]string{"bannerText": "Hi", "align": "justify"}, 400},
+		{"styled", map[string]string{"bannerText": "Hi", "style": "3d"}, 200},
+		{"styled figlet", map[string]string{"bannerText": "Hi", "font": "standard", "style": "shadow"}, 200},
+		{"unknown style", map[string]string{"bannerText": "Hi", "style": "sparkle"}, 400},
+		{"custom characters", map[string]string{"bannerText": "Hi", "characters": "#+. ", "reverse": "true"}, 200},
+		{"custom characters without a space", map[string]string{"bannerText": "Hi", "characters": "#+.", "style": "shadow"}, 200},
+		{"characters of a figlet font", map[string]string{"bannerText": "Hi", "font": "standard", "characters": "#+. "}, 400},
+		{"reversed figlet font", map[string]string{"bannerText": "Hi", "font": "standard", "reverse": "true"}, 400},
+		{"self-fill", map[string]string{"bannerText": "Hi", "selfFill": "true"}, 200},
+		{"png", map[string]string{"bannerText": "Hi", "format": "png"}, 200},
+		{"png of figlet font", map[string]string{"bannerText": "Hi", "font": "standard", "format": "png"}, 400},
+		{"unknown format", map[string]string{"bannerText": "Hi", "format": "gif"}, 400},
+		{"characters too long", map[string]string{"bannerText": "Hi", "characters": strings.Repeat("#", 100)}, 400},
+		{"sized", map[string]string{"bannerText": "Hi", "height": "4", "maxWidth": "40"}, 200},
+		{"long word in a narrow banner", map[string]string{"bannerText": strings.Repeat("W", 64), "height": "32", "maxWidth": "10"}, 200},
+		{"height too small", map[string]string{"bannerText": "Hi", "height": "1"}, 400},
+		{"width too large", map[string]string{"bannerText": "Hi", "maxWidth": "1000"}, 400},
+		{"line spacing too large", map[string]string{"bannerText": "Hi", "lineSpacing": "10"}, 400},
+		{"weight", map[string]string{"bannerText": "Hi", "font": "SourceCodePro-Italic-VariableFont_wght", "fontWeight": "700"}, 200},
+		{"weight out of range", map[string]string{"bannerText": "Hi", "font": "SourceCodePro-Italic-VariableFont_wght", "fontWeight": "1000"}, 400},
+		{"weight not a number", map[string]string{"bannerText": "Hi", "font": "SourceCodePro-Italic-VariableFont_wght", "fontWeight": "NaN"}, 400},
+		{"width the font lacks", map[string]string{"bannerText": "Hi", "font": "SourceCodePro-Italic-VariableFont_wght", "fontWidth": "75"}, 400},
+		{"weight of a static font", map[string]string{"bannerText": "Hi", "fontWeight": "700"}, 400},
+		{"weight of a figlet font", map[string]string{"bannerText": "Hi", "font": "standard", "fontWeight": "700"}, 400},
+		{"line spacing not a number", map[string]string{"bannerText": "Hi", "lineSpacing": "wide"}, 400},
+		{"stacked combining marks", map[string]string{"bannerText": "a" + strings.Repeat("\u0301", 20000)}, 400},
+		{"accented text at the limit", map[string]string{"bannerText": strings.Repeat("e\u0301\u0323", 20)}, 200},
+		{"line spacing NaN", map[string]string{"bannerText": "Hi\nthere", "lineSpacing": "NaN"}, 400},
+		{"line spacing infinite", map[string]string{"bannerText": "Hi\nthere", "lineSpacing": "Inf"}, 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, bannerRequest(tt.fields))
+			if w.Code != tt.code {
+				t.Fatalf("Expected %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+			if tt.code == 200 && strings.TrimSpace(w.Body.String()) == "" {
+				t.Error("Expected banner art in response")
+			}
+			if tt.fields["format"] == "png" && tt.code == 200 {
+				if _, err := png.Decode(w.Body); err != nil {
+					t.Errorf("Invalid PNG response: %v", err)
+				}
+			}
+		})
+	}
+
+	// Banners are rendered in memory
+	if entries, err := os.ReadDir(cfg.OutputDir); err != nil || len(entries) != 0 {
+		t.Errorf("OutputDir has %d files, want none (err %v)", len(entries), err)
+	}
+}
@@ -0,0 +1,168 @@
+package img2ascii
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"time"
+
+	"github.com/MhunterDev/img2ascii/source/ansi"
+)
+
+// ANSI renders the resampled image behind an Art as a DOS ANSI art file
+// (.ans): CP437 shade and block characters in the 16 VGA colours, followed
+// by a SAUCE record. Each cell gets the character, foreground and background
+// whose mix best matches its colour. Images wider than 80 columns are scaled
+// down to fit the DOS screen.
+type ANSI struct {
+	Title  string
+	Author string
+	Group  string
+	Date   time.Time // zero uses the current date
+}
+
+func (ANSI) ContentType() string { return "text/x-ansi; charset=cp437" }
+
+func (a ANSI) Render(w io.Writer, art *Art) error {
+	if art.raster == nil {
+		return errNoRaster
+	}
+	img := art.raster
+	if b := img.Bounds(); b.Dx() > ansi.Columns {
+		h := max(1, int(math.Round(float64(b.Dy())*ansi.Columns/float64(b.Dx()))))
+		img = resizeRGBA64(img, ansi.Columns, h)
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+
+	var buf bytes.Buffer
+	buf.WriteString("\x1b[0m")
+	cells := ansiCells()
+	cache := make(map[color.RGBA64]ansiCell)
+	fg, bg := -1, -1
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := img.RGBA64At(x, y)
+			cell, ok := cache[c]
+			if !ok {
+				cell = nearestANSICell(cells, labColor(c.R, c.G, c.B))
+				cache[c] = cell
+			}
+			// A space shows only the background and a full block only the
+			// foreground, so keep the current value for the other
+			cellFg, cellBg := cell.fg, cell.bg
+			if cell.ch == ' ' && fg >= 0 {
+				cellFg = fg
+			}
+			if cell.ch == ansi.FullBlock && bg >= 0 {
+				cellBg = bg
+			}
+			if cellFg != fg || cellBg != bg {
+				writeSGR(&buf, cellFg, cellBg)
+				fg, bg = cellFg, cellBg
+			}
+			buf.WriteByte(cell.ch)
+		}
+		// A full-width row wraps by itself; a line break would leave an
+		// empty line on an 80-column screen
+		if width < ansi.Columns {
+			buf.WriteString("\r\n")
+		}
+	}
+	buf.WriteString("\x1b[0m")
+
+	date := a.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	sauce, err := ansi.Sauce{
+		Title:    a.Title,
+		Author:   a.Author,
+		Group:    a.Group,
+		Date:     date,
+		FileSize: uint32(buf.Len()),
+		DataType: ansi.DataTypeCharacter,
+		FileType: ansi.FileTypeANSI,
+		TInfo1:   uint16(width),
+		TInfo2:   uint16(height),
+		Flags:    ansi.FlagLetterSpacing9 | ansi.FlagAspectLegacy,
+		Font:     "IBM VGA",
+	}.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	buf.Write(sauce)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// writeSGR selects a foreground (0-15) and background (0-7) colour, using
+// bold for the bright foregrounds
+func writeSGR(buf *bytes.Buffer, fg, bg int) {
+	bold := ""
+	if fg >= 8 {
+		bold = "1;"
+	}
+	fmt.Fprintf(buf, "\x1b[0;%s%d;%dm", bold, 30+fg%8, 40+bg)
+}
+
+// ansiCell is a character with its colours and the CIELAB colour of the mix
+// as seen from a distance
+type ansiCell struct {
+	ch     byte
+	fg, bg int
+	lab    [3]float64
+}
+
+// ansiCells lists every distinguishable cell: a space on each background,
+// a full block in each foreground, and the three shades of each foreground
+// over each background. Without iCE colours only the first 8 colours are
+// available as backgrounds.
+func ansiCells() []ansiCell {
+	shades := []struct {
+		ch       byte
+		coverage float64
+	}{
+		{ansi.LightShade, 0.25},
+		{ansi.MediumShade, 0.5},
+		{ansi.DarkShade, 0.75},
+	}
+	var cells []ansiCell
+	for bg := 0; bg < 8; bg++ {
+		cells = append(cells, ansiCell{ch: ' ', fg: 7, bg: bg, lab: mixLab(ansi.Palette[bg], ansi.Palette[bg], 0)})
+	}
+	for fg := 0; fg < 16; fg++ {
+		cells = append(cells, ansiCell{ch: ansi.FullBlock, fg: fg, bg: 0, lab: mixLab(ansi.Palette[fg], ansi.Palette[fg], 1)})
+		for bg := 0; bg < 8; bg++ {
+			if bg == fg {
+				continue
+			}
+			for _, s := range shades {
+				cells = append(cells, ansiCell{ch: s.ch, fg: fg, bg: bg, lab: mixLab(ansi.Palette[fg], ansi.Palette[bg], s.coverage)})
+			}
+		}
+	}
+	return cells
+}
+
+// mixLab returns the CIELAB colour of fg covering the given fraction of bg,
+// mixed in linear light
+func mixLab(fg, bg color.RGBA, coverage float64) [3]float64 {
+	mix := func(f, b uint8) uint16 {
+		l := coverage*srgbToLinear(float64(f)/0xff) + (1-coverage)*srgbToLinear(float64(b)/0xff)
+		return uint16(math.Round(linearToSRGB(l) * 0xffff))
+	}
+	return labColor(mix(fg.R, bg.R), mix(fg.G, bg.G), mix(fg.B, bg.B))
+}
+
+func nearestANSICell(cells []ansiCell, lab [3]float64) ansiCell {
+	best, bestDist := 0, math.Inf(1)
+	for i, c := range cells {
+		dl, da, db := lab[0]-c.lab[0], lab[1]-c.lab[1], lab[2]-c.lab[2]
+		if d := dl*dl + da*da + db*db; d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return cells[best]
+}
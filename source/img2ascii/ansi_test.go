@@ -0,0 +1,70 @@
+package img2ascii
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/MhunterDev/img2ascii/source/ansi"
+)
+
+func TestANSIRender(t *testing.T) {
+	testImg := createTestImage(2, 1, color.Black)
+	testImg.Set(1, 0, color.White)
+	img := &Image{Res: Resolution{Width: 2, Height: 1}, Data: testImg.Pix}
+
+	var buf bytes.Buffer
+	r := ANSI{Title: "Test", Author: "me", Date: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}
+	if err := r.Render(&buf, img.toArt(ConversionOptions{})); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	content, sauce, err := ansi.ParseSauce(buf.Bytes())
+	if err != nil || sauce == nil {
+		t.Fatalf("ParseSauce() = %v, %v", sauce, err)
+	}
+
+	want := "\x1b[0m" +
+		"\x1b[0;37;40m " + // black: a space on a black background
+		"\x1b[0;1;37;40m\xdb" + // white: a bright white full block
+		"\r\n\x1b[0m"
+	if string(content) != want {
+		t.Errorf("Content = %q, want %q", content, want)
+	}
+	if sauce.Title != "Test" || sauce.Author != "me" || sauce.Font != "IBM VGA" {
+		t.Errorf("SAUCE text = %q %q %q", sauce.Title, sauce.Author, sauce.Font)
+	}
+	if sauce.TInfo1 != 2 || sauce.TInfo2 != 1 || sauce.FileSize != uint32(len(content)) {
+		t.Errorf("SAUCE size = %dx%d, %d bytes", sauce.TInfo1, sauce.TInfo2, sauce.FileSize)
+	}
+	if sauce.DataType != ansi.DataTypeCharacter || sauce.FileType != ansi.FileTypeANSI {
+		t.Errorf("SAUCE type = %d/%d", sauce.DataType, sauce.FileType)
+	}
+}
+
+func TestANSIRenderFitsScreen(t *testing.T) {
+	img := &Image{Res: Resolution{Width: 160, Height: 10}, Data: createTestImage(160, 10, color.RGBA{R: 170, A: 255}).Pix}
+
+	var buf bytes.Buffer
+	if err := (ANSI{}).Render(&buf, img.toArt(ConversionOptions{})); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	content, sauce, err := ansi.ParseSauce(buf.Bytes())
+	if err != nil || sauce == nil {
+		t.Fatalf("ParseSauce() = %v, %v", sauce, err)
+	}
+	if sauce.TInfo1 != 80 || sauce.TInfo2 != 5 {
+		t.Errorf("SAUCE size = %dx%d, want 80x5", sauce.TInfo1, sauce.TInfo2)
+	}
+	// Full-width rows wrap on their own
+	if bytes.Contains(content, []byte("\r\n")) {
+		t.Error("80-column rows should not end in a line break")
+	}
+	// Dark red is a background colour, so each cell is a space on red
+	if !bytes.HasPrefix(content, []byte("\x1b[0m\x1b[0;37;41m ")) {
+		t.Errorf("Content starts %q, want a space on red", content[:16])
+	}
+	if n := bytes.Count(content, []byte{' '}); n != 80*5 {
+		t.Errorf("Got %d cells, want %d", n, 80*5)
+	}
+}
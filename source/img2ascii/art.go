@@ -0,0 +1,185 @@
+package img2ascii
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+)
+
+// Art is the structured result of a conversion
+type Art struct {
+	Width  int // in cells; ModeEmoji cells are two columns wide
+	Height int
+	Rows   []string
+	Ramp   string // characters used, darkest pixel first; the fill sequence for ModeTextFill, the palette for ModeEmoji
+
+	// Threshold is the cut-off applied by ModeThreshold and ModeTextFill,
+	// including one chosen automatically; zero for other modes
+	Threshold int
+
+	// Background is the display background the ramp direction was chosen
+	// for, after resolving BackgroundAuto or ConversionOptions.Reverse
+	Background Background
+
+	// Per-cell data, indexed [row][column]; nil unless requested through
+	// ConversionOptions.IncludeColors and IncludeLuminance
+	Colors    [][]color.RGBA
+	Luminance [][]int
+
+	Source  Resolution // dimensions of the decoded input image
+	Options ConversionOptions
+
+	raster *image.RGBA64 // the resampled image, for pixel formats such as Sixel
+}
+
+// String returns the art as text, one newline-terminated line per row
+func (a *Art) String() string {
+	var sb strings.Builder
+	sb.Grow((a.Width + 1) * a.Height)
+	for _, row := range a.Rows {
+		sb.WriteString(row)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// columns returns the display width of the art in terminal columns
+func (a *Art) columns() int {
+	if a.Options.Mode == ModeEmoji {
+		return 2 * a.Width
+	}
+	return a.Width
+}
+
+// cellColors returns Colors, or reads them from the raster when they were
+// not requested, for formats that colour every cell
+func (a *Art) cellColors() [][]color.RGBA {
+	if a.Colors != nil || a.raster == nil {
+		return a.Colors
+	}
+	bounds := a.raster.Bounds()
+	colors := make([][]color.RGBA, bounds.Dy())
+	for y := range colors {
+		colors[y] = make([]color.RGBA, bounds.Dx())
+		for x := range colors[y] {
+			c := a.raster.RGBA64At(bounds.Min.X+x, bounds.Min.Y+y)
+			colors[y][x] = color.RGBA{R: uint8(c.R >> 8), G: uint8(c.G >> 8), B: uint8(c.B >> 8), A: uint8(c.A >> 8)}
+		}
+	}
+	return colors
+}
+
+// cellLuminance returns Luminance, or computes it from the raster with the
+// conversion's luminance model when it was not requested
+func (a *Art) cellLuminance() [][]int {
+	if a.Luminance != nil || a.raster == nil {
+		return a.Luminance
+	}
+	bounds := a.raster.Bounds()
+	lum := make([][]int, bounds.Dy())
+	for y := range lum {
+		lum[y] = make([]int, bounds.Dx())
+		for x := range lum[y] {
+			c := a.raster.RGBA64At(bounds.Min.X+x, bounds.Min.Y+y)
+			lum[y][x] = luminanceScore(a.Options.Luminance, c.R, c.G, c.B)
+		}
+	}
+	return lum
+}
+
+type artJSON struct {
+	Width      int                `json:"width"`
+	Height     int                `json:"height"`
+	Rows       []string           `json:"rows"`
+	Ramp       string             `json:"ramp"`
+	Threshold  int                `json:"threshold,omitempty"`
+	Background string             `json:"background,omitempty"`
+	Colors     [][]string         `json:"colors,omitempty"`
+	Luminance  [][]int            `json:"luminance,omitempty"`
+	Source     resolutionJSON     `json:"source"`
+	Options    conversionOptsJSON `json:"options"`
+}
+
+type resolutionJSON struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type conversionOptsJSON struct {
+	AspectMode  string      `json:"aspectMode"`
+	FixedWidth  int         `json:"fixedWidth,omitempty"`
+	FixedHeight int         `json:"fixedHeight,omitempty"`
+	Reverse     bool        `json:"reverse"`
+	Mode        string      `json:"mode"`
+	Luminance   string      `json:"luminance"`
+	Characters  string      `json:"characters,omitempty"`
+	Threshold   int         `json:"threshold,omitempty"`
+	Levels      int         `json:"levels,omitempty"`
+	FillText    string      `json:"fillText,omitempty"`
+	FillAll     bool        `json:"fillAll,omitempty"`
+	Palette     string      `json:"palette,omitempty"`
+	Layout      *layoutJSON `json:"layout,omitempty"`
+	Background  string      `json:"targetBackground,omitempty"`
+}
+
+type layoutJSON struct {
+	Fit    string `json:"fit"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+	Budget int    `json:"budget,omitempty"`
+}
+
+// MarshalJSON encodes colours as "#rrggbb" strings and enums by name
+func (a *Art) MarshalJSON() ([]byte, error) {
+	out := artJSON{
+		Width:     a.Width,
+		Height:    a.Height,
+		Rows:      a.Rows,
+		Ramp:      a.Ramp,
+		Threshold: a.Threshold,
+		Luminance: a.Luminance,
+		Source:    resolutionJSON{Width: a.Source.Width, Height: a.Source.Height},
+		Options: conversionOptsJSON{
+			AspectMode:  a.Options.AspectMode.String(),
+			FixedWidth:  a.Options.FixedWidth,
+			FixedHeight: a.Options.FixedHeight,
+			Reverse:     a.Options.Reverse,
+			Mode:        a.Options.Mode.String(),
+			Luminance:   a.Options.Luminance.String(),
+			Characters:  a.Options.Characters,
+			Threshold:   a.Options.Threshold,
+			Levels:      a.Options.Levels,
+			FillText:    a.Options.FillText,
+			FillAll:     a.Options.FillAll,
+			Palette:     a.Options.Palette.Name,
+		},
+	}
+	if a.Background != BackgroundUnset {
+		out.Background = a.Background.String()
+	}
+	if a.Options.TargetBackground != BackgroundUnset {
+		out.Options.Background = a.Options.TargetBackground.String()
+	}
+	if l := a.Options.Layout; l != (Layout{}) {
+		out.Options.Layout = &layoutJSON{Fit: l.Fit.String(), Width: l.Width, Height: l.Height, Budget: l.Budget}
+	}
+	if out.Rows == nil {
+		out.Rows = []string{}
+	}
+	if a.Colors != nil {
+		out.Colors = make([][]string, len(a.Colors))
+		for y, row := range a.Colors {
+			out.Colors[y] = make([]string, len(row))
+			for x, c := range row {
+				out.Colors[y][x] = hexColor(c)
+			}
+		}
+	}
+	return json.Marshal(out)
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
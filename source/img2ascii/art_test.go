@@ -0,0 +1,90 @@
+package img2ascii
+
+import (
+	"encoding/json"
+	"image/color"
+	"testing"
+)
+
+func TestArtString(t *testing.T) {
+	art := &Art{Width: 3, Height: 2, Rows: []string{"@#%", ".-:"}}
+	if got, want := art.String(), "@#%\n.-:\n"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestImage_toArt(t *testing.T) {
+	width, height := 3, 2
+	testImg := createTestImage(width, height, color.RGBA{R: 255, G: 0, B: 0, A: 255})
+	img := &Image{
+		Name: "test",
+		Res:  Resolution{Width: width, Height: height},
+		Data: testImg.Pix,
+	}
+
+	art := img.toArt(ConversionOptions{})
+	if art.Colors != nil || art.Luminance != nil {
+		t.Error("Per-cell data should be omitted unless requested")
+	}
+	if art.Ramp != defaultASCIIChars {
+		t.Errorf("Ramp = %q, want %q", art.Ramp, defaultASCIIChars)
+	}
+	if got := img.toASCII(ModeDefault, false); got != art.String() {
+		t.Errorf("toASCII() = %q, want %q", got, art.String())
+	}
+
+	art = img.toArt(ConversionOptions{Reverse: true, IncludeColors: true, IncludeLuminance: true})
+	if art.Ramp != reverseString(defaultASCIIChars) {
+		t.Errorf("Reversed ramp = %q", art.Ramp)
+	}
+	if len(art.Colors) != height || len(art.Colors[0]) != width {
+		t.Fatalf("Colors has wrong shape")
+	}
+	if art.Colors[1][2] != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("Colors[1][2] = %v, want red", art.Colors[1][2])
+	}
+	if len(art.Luminance) != height || art.Luminance[0][0] != 54 {
+		t.Errorf("Luminance = %v, want 54 for red", art.Luminance)
+	}
+}
+
+func TestArtMarshalJSON(t *testing.T) {
+	art := &Art{
+		Width:     2,
+		Height:    1,
+		Rows:      []string{"@."},
+		Ramp:      defaultASCIIChars,
+		Colors:    [][]color.RGBA{{{R: 0xff, G: 0x80, B: 0x00, A: 0xff}, {A: 0xff}}},
+		Luminance: [][]int{{128, 0}},
+		Source:    Resolution{Width: 20, Height: 10},
+		Options:   ConversionOptions{AspectMode: AspectFixed, FixedWidth: 2, FixedHeight: 1, Reverse: true},
+	}
+
+	data, err := json.Marshal(art)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := `{"width":2,"height":1,"rows":["@."],"ramp":"@#%*o()1l=:-.",` +
+		`"colors":[["#ff8000","#000000"]],"luminance":[[128,0]],` +
+		`"source":{"width":20,"height":10},` +
+		`"options":{"aspectMode":"fixed","fixedWidth":2,"fixedHeight":1,"reverse":true,"mode":"default","luminance":"rec709"}}`
+	if string(data) != want {
+		t.Errorf("Marshal() =\n%s\nwant\n%s", data, want)
+	}
+
+	data, err = json.Marshal(&Art{})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := decoded["colors"]; ok {
+		t.Error("Empty art should omit colors")
+	}
+	if rows, ok := decoded["rows"].([]any); !ok || len(rows) != 0 {
+		t.Errorf("Empty art rows = %v, want []", decoded["rows"])
+	}
+}
@@ -0,0 +1,97 @@
+package img2ascii
+
+import "fmt"
+
+// Background is the colour of the surface the art will be displayed on. It
+// decides the ramp direction: dense characters read as ink, so on a dark
+// background they should represent bright pixels and on a light background
+// dark ones.
+type Background int
+
+const (
+	BackgroundUnset Background = iota // Ramp direction follows ConversionOptions.Reverse
+	BackgroundDark                    // Light text on a dark background (reversed ramp)
+	BackgroundLight                   // Dark text on a light background
+	BackgroundAuto                    // Chosen from the image's tonal background
+)
+
+func (b Background) String() string {
+	switch b {
+	case BackgroundUnset:
+		return "unset"
+	case BackgroundDark:
+		return "dark"
+	case BackgroundLight:
+		return "light"
+	case BackgroundAuto:
+		return "auto"
+	default:
+		return fmt.Sprintf("Background(%d)", int(b))
+	}
+}
+
+// ParseBackground returns the background named by String
+func ParseBackground(name string) (Background, error) {
+	for b := BackgroundUnset; b <= BackgroundAuto; b++ {
+		if b.String() == name {
+			return b, nil
+		}
+	}
+	return BackgroundUnset, fmt.Errorf("unknown background: %q", name)
+}
+
+// resolveBackground returns the concrete background (dark or light) for a
+// conversion along with whether the ramp must be reversed
+func resolveBackground(options ConversionOptions, lScores []int, width, height int) (Background, bool) {
+	bg := options.TargetBackground
+	switch bg {
+	case BackgroundAuto:
+		bg = autoBackground(lScores, width, height)
+	case BackgroundDark, BackgroundLight:
+	default:
+		if options.Reverse {
+			bg = BackgroundDark
+		} else {
+			bg = BackgroundLight
+		}
+	}
+	return bg, bg == BackgroundDark
+}
+
+// autoBackground picks the orientation that renders the image's own
+// background with sparse characters, so the subject stands out. Large flat
+// areas are usually background and edges usually subject, so the mean
+// luminance is weighted towards cells with little local contrast. A mostly
+// bright backdrop reads best as dark ink on light, a dark one as light ink.
+func autoBackground(lScores []int, width, height int) Background {
+	if width <= 0 || height <= 0 || len(lScores) < width*height {
+		return BackgroundDark
+	}
+	at := func(x, y int) int {
+		x = max(0, min(width-1, x))
+		y = max(0, min(height-1, y))
+		return lScores[y*width+x]
+	}
+	var sum, total float64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			gx := at(x+1, y) - at(x-1, y)
+			gy := at(x, y+1) - at(x, y-1)
+			gradient := float64(abs(gx) + abs(gy))
+			weight := 1 / (1 + gradient/8)
+			sum += weight * float64(at(x, y))
+			total += weight
+		}
+	}
+	if sum/total >= 128 {
+		return BackgroundLight
+	}
+	return BackgroundDark
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,115 @@
+package img2ascii
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestResolveBackground(t *testing.T) {
+	bright := []int{250, 250, 250, 250}
+	tests := []struct {
+		name       string
+		options    ConversionOptions
+		background Background
+		reverse    bool
+	}{
+		{"Unset follows Reverse", ConversionOptions{Reverse: true}, BackgroundDark, true},
+		{"Unset without Reverse", ConversionOptions{}, BackgroundLight, false},
+		{"Dark overrides Reverse", ConversionOptions{TargetBackground: BackgroundDark}, BackgroundDark, true},
+		{"Light overrides Reverse", ConversionOptions{TargetBackground: BackgroundLight, Reverse: true}, BackgroundLight, false},
+		{"Auto on bright image", ConversionOptions{TargetBackground: BackgroundAuto, Reverse: true}, BackgroundLight, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bg, reverse := resolveBackground(tt.options, bright, 2, 2)
+			if bg != tt.background || reverse != tt.reverse {
+				t.Errorf("resolveBackground() = %v, %v, want %v, %v", bg, reverse, tt.background, tt.reverse)
+			}
+		})
+	}
+}
+
+func TestAutoBackground(t *testing.T) {
+	const width, height = 10, 10
+	fill := func(f func(x, y int) int) []int {
+		scores := make([]int, width*height)
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				scores[y*width+x] = f(x, y)
+			}
+		}
+		return scores
+	}
+	inSubject := func(x, y int) bool { return x >= 3 && x < 7 && y >= 3 && y < 7 }
+
+	tests := []struct {
+		name     string
+		scores   []int
+		expected Background
+	}{
+		{"Dark subject on white", fill(func(x, y int) int {
+			if inSubject(x, y) {
+				return 10
+			}
+			return 245
+		}), BackgroundLight},
+		{"Bright subject on black", fill(func(x, y int) int {
+			if inSubject(x, y) {
+				return 245
+			}
+			return 10
+		}), BackgroundDark},
+		// Overall mean is bright, but the bright half is busy texture and the
+		// flat backdrop is dark
+		{"Textured bright area on flat dark backdrop", fill(func(x, y int) int {
+			if x < 4 {
+				return 20
+			}
+			if (x+y)%2 == 0 {
+				return 255
+			}
+			return 150
+		}), BackgroundDark},
+		{"Empty", nil, BackgroundDark},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, h := width, height
+			if tt.scores == nil {
+				w, h = 0, 0
+			}
+			if got := autoBackground(tt.scores, w, h); got != tt.expected {
+				t.Errorf("autoBackground() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestImage_toArtAutoBackground(t *testing.T) {
+	// A black dot on white must keep white as the sparse character
+	testImg := createTestImage(3, 3, color.White)
+	testImg.Set(1, 1, color.Black)
+	img := &Image{Res: Resolution{Width: 3, Height: 3}, Data: testImg.Pix}
+
+	art := img.toArt(ConversionOptions{TargetBackground: BackgroundAuto, Reverse: true})
+	if art.Background != BackgroundLight {
+		t.Errorf("Background = %v, want light", art.Background)
+	}
+	if art.Rows[1] != ".@." {
+		t.Errorf("Middle row = %q, want %q", art.Rows[1], ".@.")
+	}
+}
+
+func TestParseBackground(t *testing.T) {
+	for b := BackgroundUnset; b <= BackgroundAuto; b++ {
+		got, err := ParseBackground(b.String())
+		if err != nil || got != b {
+			t.Errorf("ParseBackground(%q) = %v, %v", b.String(), got, err)
+		}
+	}
+	if _, err := ParseBackground("grey"); err == nil {
+		t.Error("Expected error for unknown background")
+	}
+}
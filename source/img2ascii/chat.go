@@ -0,0 +1,205 @@
+package img2ascii
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrTooWide is returned by renderers whose target cannot display the art
+// at its width
+var ErrTooWide = errors.New("art too wide for format")
+
+// mircPalette holds the mIRC colours: 0-15 are the standard set every client
+// supports, 16-98 the extended palette added in mIRC 7.
+var mircPalette = [99]color.RGBA{
+	{0xff, 0xff, 0xff, 0xff}, {0x00, 0x00, 0x00, 0xff}, {0x00, 0x00, 0x7f, 0xff}, {0x00, 0x93, 0x00, 0xff},
+	{0xff, 0x00, 0x00, 0xff}, {0x7f, 0x00, 0x00, 0xff}, {0x9c, 0x00, 0x9c, 0xff}, {0xfc, 0x7f, 0x00, 0xff},
+	{0xff, 0xff, 0x00, 0xff}, {0x00, 0xfc, 0x00, 0xff}, {0x00, 0x93, 0x93, 0xff}, {0x00, 0xff, 0xff, 0xff},
+	{0x00, 0x00, 0xfc, 0xff}, {0xff, 0x00, 0xff, 0xff}, {0x7f, 0x7f, 0x7f, 0xff}, {0xd2, 0xd2, 0xd2, 0xff},
+
+	{0x47, 0x00, 0x00, 0xff}, {0x47, 0x21, 0x00, 0xff}, {0x47, 0x47, 0x00, 0xff}, {0x32, 0x47, 0x00, 0xff},
+	{0x00, 0x47, 0x00, 0xff}, {0x00, 0x47, 0x2c, 0xff}, {0x00, 0x47, 0x47, 0xff}, {0x00, 0x27, 0x47, 0xff},
+	{0x00, 0x00, 0x47, 0xff}, {0x2e, 0x00, 0x47, 0xff}, {0x47, 0x00, 0x47, 0xff}, {0x47, 0x00, 0x2a, 0xff},
+	{0x74, 0x00, 0x00, 0xff}, {0x74, 0x3a, 0x00, 0xff}, {0x74, 0x74, 0x00, 0xff}, {0x51, 0x74, 0x00, 0xff},
+	{0x00, 0x74, 0x00, 0xff}, {0x00, 0x74, 0x49, 0xff}, {0x00, 0x74, 0x74, 0xff}, {0x00, 0x40, 0x74, 0xff},
+	{0x00, 0x00, 0x74, 0xff}, {0x4b, 0x00, 0x74, 0xff}, {0x74, 0x00, 0x74, 0xff}, {0x74, 0x00, 0x45, 0xff},
+	{0xb5, 0x00, 0x00, 0xff}, {0xb5, 0x63, 0x00, 0xff}, {0xb5, 0xb5, 0x00, 0xff}, {0x7d, 0xb5, 0x00, 0xff},
+	{0x00, 0xb5, 0x00, 0xff}, {0x00, 0xb5, 0x71, 0xff}, {0x00, 0xb5, 0xb5, 0xff}, {0x00, 0x63, 0xb5, 0xff},
+	{0x00, 0x00, 0xb5, 0xff}, {0x75, 0x00, 0xb5, 0xff}, {0xb5, 0x00, 0xb5, 0xff}, {0xb5, 0x00, 0x6b, 0xff},
+	{0xff, 0x00, 0x00, 0xff}, {0xff, 0x8c, 0x00, 0xff}, {0xff, 0xff, 0x00, 0xff}, {0xb2, 0xff, 0x00, 0xff},
+	{0x00, 0xff, 0x00, 0xff}, {0x00, 0xff, 0xa0, 0xff}, {0x00, 0xff, 0xff, 0xff}, {0x00, 0x8c, 0xff, 0xff},
+	{0x00, 0x00, 0xff, 0xff}, {0xa5, 0x00, 0xff, 0xff}, {0xff, 0x00, 0xff, 0xff}, {0xff, 0x00, 0x98, 0xff},
+	{0xff, 0x59, 0x59, 0xff}, {0xff, 0xb4, 0x59, 0xff}, {0xff, 0xff, 0x71, 0xff}, {0xcf, 0xff, 0x60, 0xff},
+	{0x6f, 0xff, 0x6f, 0xff}, {0x65, 0xff, 0xc9, 0xff}, {0x6d, 0xff, 0xff, 0xff}, {0x59, 0xb4, 0xff, 0xff},
+	{0x59, 0x59, 0xff, 0xff}, {0xc4, 0x59, 0xff, 0xff}, {0xff, 0x66, 0xff, 0xff}, {0xff, 0x59, 0xbc, 0xff},
+	{0xff, 0x9c, 0x9c, 0xff}, {0xff, 0xd3, 0x9c, 0xff}, {0xff, 0xff, 0x9c, 0xff}, {0xe2, 0xff, 0x9c, 0xff},
+	{0x9c, 0xff, 0x9c, 0xff}, {0x9c, 0xff, 0xdb, 0xff}, {0x9c, 0xff, 0xff, 0xff}, {0x9c, 0xd3, 0xff, 0xff},
+	{0x9c, 0x9c, 0xff, 0xff}, {0xdc, 0x9c, 0xff, 0xff}, {0xff, 0x9c, 0xff, 0xff}, {0xff, 0x94, 0xd3, 0xff},
+	{0x00, 0x00, 0x00, 0xff}, {0x13, 0x13, 0x13, 0xff}, {0x28, 0x28, 0x28, 0xff}, {0x36, 0x36, 0x36, 0xff},
+	{0x4d, 0x4d, 0x4d, 0xff}, {0x65, 0x65, 0x65, 0xff}, {0x81, 0x81, 0x81, 0xff}, {0x9f, 0x9f, 0x9f, 0xff},
+	{0xbc, 0xbc, 0xbc, 0xff}, {0xe2, 0xe2, 0xe2, 0xff}, {0xff, 0xff, 0xff, 0xff},
+}
+
+// IRC renders the rows with mIRC colour codes, colouring each character with
+// the nearest palette entry to its cell. Codes are only emitted when the
+// colour changes, and always with two digits so art containing digits is not
+// misread.
+type IRC struct {
+	Basic bool // only the 16 standard colours, for older clients
+}
+
+func (IRC) ContentType() string { return "text/plain; charset=utf-8" }
+
+func (r IRC) Render(w io.Writer, art *Art) error {
+	palette := mircPalette[:]
+	if r.Basic {
+		palette = palette[:16]
+	}
+	nearest := newPaletteMatcher(palette)
+	colors := art.cellColors()
+	bw := bufio.NewWriter(w)
+	for y, row := range art.Rows {
+		current := -1
+		for x, cell := range SplitCells(row) {
+			if cell != " " && visible(colors, x, y) {
+				if n := nearest.index(colors[y][x]); n != current {
+					// A comma straight after the code would start a
+					// background colour, so give the default background
+					if cell == "," {
+						fmt.Fprintf(bw, "\x03%02d,99", n)
+					} else {
+						fmt.Fprintf(bw, "\x03%02d", n)
+					}
+					current = n
+				}
+			}
+			bw.WriteString(cell)
+		}
+		if current >= 0 {
+			bw.WriteByte(0x0f) // reset so the next line starts plain
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// BBCode renders the rows with [color] tags for forums. Colours are rounded
+// to the 216 web-safe colours so neighbouring cells share tags, and square
+// brackets in the art become parentheses so they cannot form tags.
+type BBCode struct{}
+
+func (BBCode) ContentType() string { return "text/plain; charset=utf-8" }
+
+func (BBCode) Render(w io.Writer, art *Art) error {
+	colors := art.cellColors()
+	bw := bufio.NewWriter(w)
+	for y, row := range art.Rows {
+		open := ""
+		for x, cell := range SplitCells(row) {
+			switch cell {
+			case "[":
+				cell = "("
+			case "]":
+				cell = ")"
+			}
+			if cell != " " && visible(colors, x, y) {
+				if c := webSafe(colors[y][x]); c != open {
+					if open != "" {
+						bw.WriteString("[/color]")
+					}
+					fmt.Fprintf(bw, "[color=%s]", c)
+					open = c
+				}
+			}
+			bw.WriteString(cell)
+		}
+		if open != "" {
+			bw.WriteString("[/color]")
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// visible reports whether the cell at (x, y) has a colour worth showing
+func visible(colors [][]color.RGBA, x, y int) bool {
+	return y < len(colors) && x < len(colors[y]) && colors[y][x].A >= 0x80
+}
+
+// webSafe rounds c to the nearest web-safe colour
+func webSafe(c color.RGBA) string {
+	round := func(v uint8) uint8 { return uint8((int(v) + 0x19) / 0x33 * 0x33) }
+	return hexColor(color.RGBA{R: round(c.R), G: round(c.G), B: round(c.B)})
+}
+
+// Markdown renders the rows in a fenced code block for chat apps that
+// render Markdown. Art wider than MaxWidth columns, or longer than
+// MaxLength characters in total, fails with ErrTooWide rather than being
+// wrapped by the client.
+type Markdown struct {
+	MaxWidth  int // columns; 0 means no limit
+	MaxLength int // characters including the fence; 0 means no limit
+}
+
+func (Markdown) ContentType() string { return "text/markdown; charset=utf-8" }
+
+func (m Markdown) Render(w io.Writer, art *Art) error {
+	if cols := art.columns(); m.MaxWidth > 0 && cols > m.MaxWidth {
+		return fmt.Errorf("%w: %d columns, at most %d", ErrTooWide, cols, m.MaxWidth)
+	}
+	// The fence must be longer than any run of backticks in the art
+	fence := "```"
+	for _, row := range art.Rows {
+		for strings.Contains(row, fence) {
+			fence += "`"
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString(fence + "text\n")
+	for _, row := range art.Rows {
+		sb.WriteString(row)
+		sb.WriteByte('\n')
+	}
+	sb.WriteString(fence + "\n")
+	if n := utf8.RuneCountInString(sb.String()); m.MaxLength > 0 && n > m.MaxLength {
+		return fmt.Errorf("%w: %d characters, at most %d", ErrTooWide, n, m.MaxLength)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// paletteMatcher matches colours to a palette in CIELAB space, caching results
+type paletteMatcher struct {
+	lab   [][3]float64
+	cache map[color.RGBA]int
+}
+
+func newPaletteMatcher(palette []color.RGBA) *paletteMatcher {
+	n := &paletteMatcher{lab: make([][3]float64, len(palette)), cache: make(map[color.RGBA]int)}
+	for i, c := range palette {
+		n.lab[i] = labColor(uint16(c.R)*0x101, uint16(c.G)*0x101, uint16(c.B)*0x101)
+	}
+	return n
+}
+
+func (n *paletteMatcher) index(c color.RGBA) int {
+	if i, ok := n.cache[c]; ok {
+		return i
+	}
+	lab := labColor(uint16(c.R)*0x101, uint16(c.G)*0x101, uint16(c.B)*0x101)
+	best, bestDist := 0, math.Inf(1)
+	for i, p := range n.lab {
+		dl, da, db := lab[0]-p[0], lab[1]-p[1], lab[2]-p[2]
+		if d := dl*dl + da*da + db*db; d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	n.cache[c] = best
+	return best
+}
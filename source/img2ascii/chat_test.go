@@ -0,0 +1,162 @@
+package img2ascii
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+var (
+	red  = color.RGBA{R: 0xff, A: 0xff}
+	blue = color.RGBA{B: 0xff, A: 0xff}
+	none = color.RGBA{}
+)
+
+func TestIRCRender(t *testing.T) {
+	art := &Art{
+		Width:  3,
+		Height: 3,
+		Rows:   []string{"@1 ", ",2.", "..."},
+		Colors: [][]color.RGBA{
+			{red, red, red},
+			{blue, blue, red},
+			{none, none, none},
+		},
+	}
+	tests := []struct {
+		name     string
+		r        IRC
+		expected string
+	}{
+		{"extended", IRC{}, "\x0304@1 \x0f\n\x0360,99,2\x0304.\x0f\n...\n"},
+		{"basic", IRC{Basic: true}, "\x0304@1 \x0f\n\x0312,99,2\x0304.\x0f\n...\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.r.Render(&buf, art); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("Render() = %q, want %q", buf.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestIRCRenderUsesRaster(t *testing.T) {
+	testImg := createTestImage(2, 1, color.RGBA{G: 0xff, A: 0xff})
+	img := &Image{Res: Resolution{Width: 2, Height: 1}, Data: testImg.Pix}
+
+	var buf bytes.Buffer
+	if err := (IRC{}).Render(&buf, img.toArt(ConversionOptions{})); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "\x0356") {
+		t.Errorf("Render() = %q, want green colour codes without IncludeColors", buf.String())
+	}
+}
+
+func TestBBCodeRender(t *testing.T) {
+	art := &Art{
+		Width:  4,
+		Height: 2,
+		Rows:   []string{"[a] ", "ab"},
+		Colors: [][]color.RGBA{
+			{red, {R: 0xf0, G: 0x10, A: 0xff}, blue, blue},
+		},
+	}
+	var buf bytes.Buffer
+	if err := (BBCode{}).Render(&buf, art); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := "[color=#ff0000](a[/color][color=#0000ff]) [/color]\nab\n"
+	if buf.String() != want {
+		t.Errorf("Render() = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestChatRenderEmojiCells(t *testing.T) {
+	// The heart carries a variation selector and the thumb a skin tone, so
+	// each cell spans two runes
+	art := &Art{
+		Width:  3,
+		Height: 1,
+		Rows:   []string{"\u2764\ufe0f\U0001f44d\U0001f3fd#"},
+		Colors: [][]color.RGBA{{red, red, blue}},
+	}
+	tests := []struct {
+		name     string
+		r        Renderer
+		expected string
+	}{
+		{"IRC", IRC{}, "\x0304\u2764\ufe0f\U0001f44d\U0001f3fd\x0360#\x0f\n"},
+		{"BBCode", BBCode{}, "[color=#ff0000]\u2764\ufe0f\U0001f44d\U0001f3fd[/color][color=#0000ff]#[/color]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.r.Render(&buf, art); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("Render() = %q, want %q", buf.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestMarkdownRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        Markdown
+		art      *Art
+		expected string
+		err      error
+	}{
+		{
+			name:     "fenced",
+			m:        Markdown{MaxWidth: 80},
+			art:      &Art{Width: 2, Height: 2, Rows: []string{"@.", ".@"}},
+			expected: "```text\n@.\n.@\n```\n",
+		},
+		{
+			name:     "backticks in art",
+			m:        Markdown{},
+			art:      &Art{Width: 4, Height: 1, Rows: []string{"````"}},
+			expected: "`````text\n````\n`````\n",
+		},
+		{
+			name: "too wide",
+			m:    Markdown{MaxWidth: 3},
+			art:  &Art{Width: 4, Height: 1, Rows: []string{"@@@@"}},
+			err:  ErrTooWide,
+		},
+		{
+			name: "wide emoji cells",
+			m:    Markdown{MaxWidth: 3},
+			art:  &Art{Width: 2, Height: 1, Rows: []string{"🟥🟥"}, Options: ConversionOptions{Mode: ModeEmoji}},
+			err:  ErrTooWide,
+		},
+		{
+			name: "too long",
+			m:    Markdown{MaxLength: 10},
+			art:  &Art{Width: 2, Height: 2, Rows: []string{"@.", ".@"}},
+			err:  ErrTooWide,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := tt.m.Render(&buf, tt.art)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Render() error = %v, want %v", err, tt.err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("Render() = %q, want %q", buf.String(), tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package img2ascii
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// EmojiSwatch is an emoji annotated with the colour it renders as
+type EmojiSwatch struct {
+	Emoji string
+	Color color.RGBA
+}
+
+// EmojiPalette is a named set of emoji that ModeEmoji picks from. Each
+// emoji occupies two terminal columns.
+type EmojiPalette struct {
+	Name     string
+	Swatches []EmojiSwatch
+}
+
+// Built-in palettes. Colours follow the common Twemoji artwork; other emoji
+// fonts are close enough for nearest-colour matching.
+var (
+	EmojiSquares = EmojiPalette{Name: "squares", Swatches: []EmojiSwatch{
+		{"🟥", color.RGBA{0xdd, 0x2e, 0x44, 0xff}}, // red
+		{"🟧", color.RGBA{0xf4, 0x90, 0x0c, 0xff}}, // orange
+		{"🟨", color.RGBA{0xfd, 0xcb, 0x58, 0xff}}, // yellow
+		{"🟩", color.RGBA{0x78, 0xb1, 0x59, 0xff}}, // green
+		{"🟦", color.RGBA{0x55, 0xac, 0xee, 0xff}}, // blue
+		{"🟪", color.RGBA{0xaa, 0x8e, 0xd6, 0xff}}, // purple
+		{"🟫", color.RGBA{0xc1, 0x69, 0x4f, 0xff}}, // brown
+		{"⬛", color.RGBA{0x31, 0x37, 0x3d, 0xff}}, // black
+		{"⬜", color.RGBA{0xe6, 0xe7, 0xe8, 0xff}}, // white
+	}}
+	EmojiCircles = EmojiPalette{Name: "circles", Swatches: []EmojiSwatch{
+		{"🔴", color.RGBA{0xdd, 0x2e, 0x44, 0xff}}, // red
+		{"🟠", color.RGBA{0xf4, 0x90, 0x0c, 0xff}}, // orange
+		{"🟡", color.RGBA{0xfd, 0xcb, 0x58, 0xff}}, // yellow
+		{"🟢", color.RGBA{0x78, 0xb1, 0x59, 0xff}}, // green
+		{"🔵", color.RGBA{0x55, 0xac, 0xee, 0xff}}, // blue
+		{"🟣", color.RGBA{0xaa, 0x8e, 0xd6, 0xff}}, // purple
+		{"🟤", color.RGBA{0xc1, 0x69, 0x4f, 0xff}}, // brown
+		{"⚫", color.RGBA{0x31, 0x37, 0x3d, 0xff}}, // black
+		{"⚪", color.RGBA{0xe6, 0xe7, 0xe8, 0xff}}, // white
+	}}
+	EmojiHearts = EmojiPalette{Name: "hearts", Swatches: []EmojiSwatch{
+		{"\u2764\ufe0f", color.RGBA{0xdd, 0x2e, 0x44, 0xff}}, // red, with the emoji presentation selector
+		{"🧡", color.RGBA{0xf4, 0x90, 0x0c, 0xff}},            // orange
+		{"💛", color.RGBA{0xfd, 0xcb, 0x58, 0xff}},            // yellow
+		{"💚", color.RGBA{0x78, 0xb1, 0x59, 0xff}},            // green
+		{"💙", color.RGBA{0x5d, 0xad, 0xec, 0xff}},            // blue
+		{"💜", color.RGBA{0xaa, 0x8e, 0xd6, 0xff}},            // purple
+		{"🤎", color.RGBA{0xc1, 0x69, 0x4f, 0xff}},            // brown
+		{"🖤", color.RGBA{0x31, 0x37, 0x3d, 0xff}},            // black
+		{"🤍", color.RGBA{0xe6, 0xe7, 0xe8, 0xff}},            // white
+	}}
+)
+
+var emojiPalettes = []EmojiPalette{EmojiSquares, EmojiCircles, EmojiHearts}
+
+// ParseEmojiPalette returns the built-in palette with the given name
+func ParseEmojiPalette(name string) (EmojiPalette, error) {
+	for _, p := range emojiPalettes {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return EmojiPalette{}, fmt.Errorf("unknown emoji palette: %q", name)
+}
+
+// EmojiPaletteNames returns the names of the built-in palettes
+func EmojiPaletteNames() []string {
+	names := make([]string, len(emojiPalettes))
+	for i, p := range emojiPalettes {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// emojiMapper picks the swatch nearest to a cell's colour in CIELAB space
+type emojiMapper struct {
+	palette EmojiPalette
+	lab     [][3]float64
+}
+
+func newEmojiMapper(palette EmojiPalette) emojiMapper {
+	if len(palette.Swatches) == 0 {
+		palette = EmojiSquares
+	}
+	lab := make([][3]float64, len(palette.Swatches))
+	for i, s := range palette.Swatches {
+		lab[i] = labColor(uint16(s.Color.R)*0x101, uint16(s.Color.G)*0x101, uint16(s.Color.B)*0x101)
+	}
+	return emojiMapper{palette: palette, lab: lab}
+}
+
+func (m emojiMapper) emoji(r, g, b uint16) string {
+	c := labColor(r, g, b)
+	best, bestDist := 0, math.Inf(1)
+	for i, s := range m.lab {
+		dl, da, db := c[0]-s[0], c[1]-s[1], c[2]-s[2]
+		if d := dl*dl + da*da + db*db; d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return m.palette.Swatches[best].Emoji
+}
+
+// ramp returns the palette's emoji in order
+func (m emojiMapper) ramp() string {
+	var sb strings.Builder
+	for _, s := range m.palette.Swatches {
+		sb.WriteString(s.Emoji)
+	}
+	return sb.String()
+}
+
+// labColor converts 16-bit sRGB to CIELAB (D65 white point)
+func labColor(r, g, b uint16) [3]float64 {
+	const max = 0xffff
+	rl := srgbToLinear(float64(r) / max)
+	gl := srgbToLinear(float64(g) / max)
+	bl := srgbToLinear(float64(b) / max)
+
+	x := (0.4124*rl + 0.3576*gl + 0.1805*bl) / 0.95047
+	y := 0.2126*rl + 0.7152*gl + 0.0722*bl
+	z := (0.0193*rl + 0.1192*gl + 0.9505*bl) / 1.08883
+
+	f := func(t float64) float64 {
+		const epsilon = 216.0 / 24389.0
+		const kappa = 24389.0 / 27.0
+		if t > epsilon {
+			return math.Cbrt(t)
+		}
+		return (kappa*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return [3]float64{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
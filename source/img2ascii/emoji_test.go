@@ -0,0 +1,97 @@
+package img2ascii
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEmojiMapper(t *testing.T) {
+	tests := []struct {
+		name     string
+		palette  EmojiPalette
+		color    color.RGBA64
+		expected string
+	}{
+		{"Red square", EmojiSquares, color.RGBA64{R: 0xffff, A: 0xffff}, "🟥"},
+		{"Blue square", EmojiSquares, color.RGBA64{R: 0x5000, G: 0xa000, B: 0xf000, A: 0xffff}, "🟦"},
+		{"White square", EmojiSquares, color.RGBA64{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff}, "⬜"},
+		{"Black circle", EmojiCircles, color.RGBA64{A: 0xffff}, "⚫"},
+		{"Yellow heart", EmojiHearts, color.RGBA64{R: 0xffff, G: 0xe000, A: 0xffff}, "💛"},
+		{"Zero palette uses squares", EmojiPalette{}, color.RGBA64{G: 0xa000, A: 0xffff}, "🟩"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newEmojiMapper(tt.palette)
+			if got := m.emoji(tt.color.R, tt.color.G, tt.color.B); got != tt.expected {
+				t.Errorf("emoji(%v) = %s, want %s", tt.color, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestImage_toArtEmoji(t *testing.T) {
+	testImg := createTestImage(3, 1, color.RGBA{R: 220, G: 40, B: 60, A: 255})
+	testImg.Set(1, 0, color.RGBA{R: 80, G: 170, B: 240, A: 255})
+	img := &Image{Res: Resolution{Width: 3, Height: 1}, Data: testImg.Pix}
+
+	art := img.toArt(ConversionOptions{Mode: ModeEmoji, Palette: EmojiCircles})
+	if art.Rows[0] != "🔴🔵🔴" {
+		t.Errorf("Row = %q, want %q", art.Rows[0], "🔴🔵🔴")
+	}
+	if art.Width != 3 {
+		t.Errorf("Width = %d cells, want 3", art.Width)
+	}
+	if art.Ramp != "🔴🟠🟡🟢🔵🟣🟤⚫⚪" {
+		t.Errorf("Ramp = %q, want the circles palette", art.Ramp)
+	}
+}
+
+func TestConvertEmojiHalvesWidth(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, createTestImage(40, 20, color.White)); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	imgPath := writeTestFile(t, buf.Bytes())
+
+	tests := []struct {
+		name          string
+		layout        Layout
+		width, height int
+	}{
+		{"Stretch", Layout{Fit: FitStretch, Width: 20, Height: 5}, 10, 5},
+		{"Contain", Layout{Fit: FitContain, Width: 20, Height: 20}, 10, 5},
+		{"Height", Layout{Fit: FitHeight, Height: 6}, 12, 6},
+		{"Budget", Layout{Fit: FitBudget, Budget: 100}, 10, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			art, err := Convert(imgPath, ConversionOptions{Mode: ModeEmoji, Layout: tt.layout})
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if art.Width != tt.width || art.Height != tt.height {
+				t.Errorf("Grid = %dx%d, want %dx%d", art.Width, art.Height, tt.width, tt.height)
+			}
+			if n := utf8.RuneCountInString(art.Rows[0]); n != tt.width {
+				t.Errorf("Row has %d runes, want %d emoji", n, tt.width)
+			}
+		})
+	}
+}
+
+func TestParseEmojiPalette(t *testing.T) {
+	for _, name := range EmojiPaletteNames() {
+		p, err := ParseEmojiPalette(name)
+		if err != nil || p.Name != name || len(p.Swatches) == 0 {
+			t.Errorf("ParseEmojiPalette(%q) = %+v, %v", name, p, err)
+		}
+	}
+	if _, err := ParseEmojiPalette("flags"); err == nil {
+		t.Error("Expected error for unknown palette")
+	}
+}
@@ -0,0 +1,297 @@
+package img2ascii
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Filter transforms an image before its pixels are mapped to characters.
+// Filters run on the resampled grid (one pixel per character cell) and may
+// modify img in place or return a new image of the same size.
+type Filter interface {
+	Apply(img *image.RGBA64) *image.RGBA64
+}
+
+// FilterFunc adapts an ordinary function to the Filter interface
+type FilterFunc func(img *image.RGBA64) *image.RGBA64
+
+func (f FilterFunc) Apply(img *image.RGBA64) *image.RGBA64 {
+	return f(img)
+}
+
+// Pipeline applies its filters in order. A Pipeline is itself a Filter.
+type Pipeline []Filter
+
+func (p Pipeline) Apply(img *image.RGBA64) *image.RGBA64 {
+	for _, f := range p {
+		if f != nil {
+			img = f.Apply(img)
+		}
+	}
+	return img
+}
+
+var (
+	filtersMu sync.RWMutex
+	filters   = make(map[string]func() Filter)
+)
+
+// RegisterFilter makes a filter available by name, e.g. to NewFilter and the
+// server's upload form. factory returns the filter with default settings.
+// It panics if name is empty or already registered.
+func RegisterFilter(name string, factory func() Filter) {
+	filtersMu.Lock()
+	defer filtersMu.Unlock()
+	if name == "" || factory == nil {
+		panic("img2ascii: RegisterFilter requires a name and factory")
+	}
+	if _, dup := filters[name]; dup {
+		panic("img2ascii: RegisterFilter called twice for filter " + name)
+	}
+	filters[name] = factory
+}
+
+// NewFilter returns the registered filter called name with default settings
+func NewFilter(name string) (Filter, error) {
+	filtersMu.RLock()
+	factory, ok := filters[name]
+	filtersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown filter: %q", name)
+	}
+	return factory(), nil
+}
+
+// FilterNames returns the names of all registered filters, sorted
+func FilterNames() []string {
+	filtersMu.RLock()
+	defer filtersMu.RUnlock()
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterFilter("blur", func() Filter { return Blur{Sigma: 1} })
+	RegisterFilter("sharpen", func() Filter { return Sharpen{Sigma: 1, Amount: 1} })
+	RegisterFilter("emboss", func() Filter { return Emboss{} })
+	RegisterFilter("invert", func() Filter { return Invert{} })
+	RegisterFilter("desaturate", func() Filter { return Desaturate{} })
+	RegisterFilter("vignette", func() Filter { return Vignette{Strength: 0.5} })
+}
+
+// Blur applies a Gaussian blur with standard deviation Sigma, in cells
+type Blur struct {
+	Sigma float64
+}
+
+func (f Blur) Apply(img *image.RGBA64) *image.RGBA64 {
+	if f.Sigma <= 0 {
+		return img
+	}
+	return fromPlanes(gaussianBlur(toPlanes(img), f.Sigma), img.Rect)
+}
+
+// Sharpen applies an unsharp mask: the difference between the image and a
+// Gaussian blur of radius Sigma is scaled by Amount and added back
+type Sharpen struct {
+	Sigma  float64
+	Amount float64
+}
+
+func (f Sharpen) Apply(img *image.RGBA64) *image.RGBA64 {
+	if f.Sigma <= 0 || f.Amount == 0 {
+		return img
+	}
+	src := toPlanes(img)
+	blurred := gaussianBlur(src, f.Sigma)
+	for c := 0; c < 3; c++ {
+		for i, v := range src.data[c] {
+			blurred.data[c][i] = v + f.Amount*(v-blurred.data[c][i])
+		}
+	}
+	blurred.data[3] = src.data[3]
+	return fromPlanes(blurred, img.Rect)
+}
+
+// Emboss replaces each pixel with a directional relief of its neighbourhood
+type Emboss struct{}
+
+func (Emboss) Apply(img *image.RGBA64) *image.RGBA64 {
+	kernel := [3][3]float64{
+		{-2, -1, 0},
+		{-1, 1, 1},
+		{0, 1, 2},
+	}
+	src := toPlanes(img)
+	dst := src.clone()
+	for c := 0; c < 3; c++ {
+		for y := 0; y < src.h; y++ {
+			for x := 0; x < src.w; x++ {
+				var sum float64
+				for ky := -1; ky <= 1; ky++ {
+					for kx := -1; kx <= 1; kx++ {
+						sum += kernel[ky+1][kx+1] * src.at(c, x+kx, y+ky)
+					}
+				}
+				dst.data[c][y*src.w+x] = sum
+			}
+		}
+	}
+	return fromPlanes(dst, img.Rect)
+}
+
+// Invert replaces each colour with its complement, keeping alpha. Colours
+// are premultiplied, so each channel becomes alpha minus the channel.
+type Invert struct{}
+
+func (Invert) Apply(img *image.RGBA64) *image.RGBA64 {
+	for i := 0; i+7 < len(img.Pix); i += 8 {
+		a := uint16(img.Pix[i+6])<<8 | uint16(img.Pix[i+7])
+		for c := 0; c < 6; c += 2 {
+			v := a - min(a, uint16(img.Pix[i+c])<<8|uint16(img.Pix[i+c+1]))
+			img.Pix[i+c], img.Pix[i+c+1] = uint8(v>>8), uint8(v)
+		}
+	}
+	return img
+}
+
+// Desaturate converts the image to grey using Rec.709 weights
+type Desaturate struct{}
+
+func (Desaturate) Apply(img *image.RGBA64) *image.RGBA64 {
+	planes := toPlanes(img)
+	for i := range planes.data[0] {
+		y := 0.2126*planes.data[0][i] + 0.7152*planes.data[1][i] + 0.0722*planes.data[2][i]
+		planes.data[0][i], planes.data[1][i], planes.data[2][i] = y, y, y
+	}
+	return fromPlanes(planes, img.Rect)
+}
+
+// Vignette darkens the image towards its corners. Strength 0 leaves the
+// image unchanged; 1 fades the corners to black.
+type Vignette struct {
+	Strength float64
+}
+
+func (f Vignette) Apply(img *image.RGBA64) *image.RGBA64 {
+	planes := toPlanes(img)
+	cx, cy := float64(planes.w-1)/2, float64(planes.h-1)/2
+	maxDist := math.Hypot(cx, cy)
+	if maxDist == 0 {
+		return img
+	}
+	for y := 0; y < planes.h; y++ {
+		for x := 0; x < planes.w; x++ {
+			d := math.Hypot(float64(x)-cx, float64(y)-cy) / maxDist
+			factor := 1 - f.Strength*d*d
+			i := y*planes.w + x
+			for c := 0; c < 3; c++ {
+				planes.data[c][i] *= factor
+			}
+		}
+	}
+	return fromPlanes(planes, img.Rect)
+}
+
+// planes holds an image as separate float channels (R, G, B, A) in [0, 1]
+type planes struct {
+	w, h int
+	data [4][]float64
+}
+
+func toPlanes(img *image.RGBA64) planes {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	p := planes{w: w, h: h}
+	for c := range p.data {
+		p.data[c] = make([]float64, w*h)
+	}
+	for y := 0; y < h; y++ {
+		row := img.Pix[y*img.Stride:]
+		for x := 0; x < w; x++ {
+			for c := 0; c < 4; c++ {
+				v := uint16(row[x*8+c*2])<<8 | uint16(row[x*8+c*2+1])
+				p.data[c][y*w+x] = float64(v) / 0xffff
+			}
+		}
+	}
+	return p
+}
+
+// fromPlanes converts p back to an image, clamping each channel to [0, 1]
+// and the colour channels to alpha so the pixels stay premultiplied
+func fromPlanes(p planes, rect image.Rectangle) *image.RGBA64 {
+	img := image.NewRGBA64(rect)
+	for y := 0; y < p.h; y++ {
+		row := img.Pix[y*img.Stride:]
+		for x := 0; x < p.w; x++ {
+			alpha := math.Max(0, math.Min(1, p.data[3][y*p.w+x]))
+			for c := 0; c < 4; c++ {
+				v := math.Round(math.Max(0, math.Min(alpha, p.data[c][y*p.w+x])) * 0xffff)
+				row[x*8+c*2] = uint8(uint16(v) >> 8)
+				row[x*8+c*2+1] = uint8(v)
+			}
+		}
+	}
+	return img
+}
+
+func (p planes) clone() planes {
+	out := planes{w: p.w, h: p.h}
+	for c := range p.data {
+		out.data[c] = append([]float64(nil), p.data[c]...)
+	}
+	return out
+}
+
+// at returns channel c at (x, y), clamping coordinates to the edges
+func (p planes) at(c, x, y int) float64 {
+	x = max(0, min(p.w-1, x))
+	y = max(0, min(p.h-1, y))
+	return p.data[c][y*p.w+x]
+}
+
+// gaussianBlur blurs the colour channels with a separable Gaussian kernel
+func gaussianBlur(src planes, sigma float64) planes {
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	var total float64
+	for i := range kernel {
+		d := float64(i - radius)
+		kernel[i] = math.Exp(-d * d / (2 * sigma * sigma))
+		total += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= total
+	}
+
+	tmp := src.clone()
+	dst := src.clone()
+	for c := 0; c < 3; c++ {
+		for y := 0; y < src.h; y++ {
+			for x := 0; x < src.w; x++ {
+				var sum float64
+				for k, w := range kernel {
+					sum += w * src.at(c, x+k-radius, y)
+				}
+				tmp.data[c][y*src.w+x] = sum
+			}
+		}
+		for y := 0; y < src.h; y++ {
+			for x := 0; x < src.w; x++ {
+				var sum float64
+				for k, w := range kernel {
+					sum += w * tmp.at(c, x, y+k-radius)
+				}
+				dst.data[c][y*src.w+x] = sum
+			}
+		}
+	}
+	return dst
+}
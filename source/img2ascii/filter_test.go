@@ -0,0 +1,193 @@
+package img2ascii
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestFiltersOnUniformImage(t *testing.T) {
+	gray := color.RGBA64{R: 0x8000, G: 0x8000, B: 0x8000, A: 0xffff}
+	tests := []struct {
+		name   string
+		filter Filter
+	}{
+		{"Blur", Blur{Sigma: 1.5}},
+		{"Sharpen", Sharpen{Sigma: 1, Amount: 2}},
+		{"Emboss", Emboss{}},
+		{"Desaturate", Desaturate{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := createTestImage(5, 4, gray)
+			out := tt.filter.Apply(img)
+			if out.Bounds() != img.Bounds() {
+				t.Fatalf("Bounds = %v, want %v", out.Bounds(), img.Bounds())
+			}
+			for y := 0; y < 4; y++ {
+				for x := 0; x < 5; x++ {
+					if got := out.RGBA64At(x, y); got != gray {
+						t.Fatalf("Pixel (%d, %d) = %v, want unchanged %v", x, y, got, gray)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestInvert(t *testing.T) {
+	img := createTestImage(2, 1, color.RGBA64{R: 0x1234, G: 0, B: 0xffff, A: 0xffff})
+	out := Invert{}.Apply(img)
+	want := color.RGBA64{R: 0xedcb, G: 0xffff, B: 0, A: 0xffff}
+	if got := out.RGBA64At(1, 0); got != want {
+		t.Errorf("Inverted pixel = %v, want %v", got, want)
+	}
+
+	// A half-transparent pixel stays a valid premultiplied colour: no
+	// channel exceeds alpha
+	img = createTestImage(1, 1, color.RGBA64{R: 0x1000, G: 0, B: 0x8000, A: 0x8000})
+	want = color.RGBA64{R: 0x7000, G: 0x8000, B: 0, A: 0x8000}
+	if got := (Invert{}).Apply(img).RGBA64At(0, 0); got != want {
+		t.Errorf("Inverted translucent pixel = %v, want %v", got, want)
+	}
+}
+
+func TestDesaturate(t *testing.T) {
+	img := createTestImage(1, 1, color.RGBA64{R: 0xffff, A: 0xffff})
+	got := Desaturate{}.Apply(img).RGBA64At(0, 0)
+	if got.R != got.G || got.G != got.B {
+		t.Errorf("Desaturated pixel %v is not grey", got)
+	}
+	if want := uint16(13933); got.R != want { // 0.2126 * 0xffff
+		t.Errorf("Grey level = %#x, want %#x", got.R, want)
+	}
+}
+
+func TestFiltersKeepPremultipliedColours(t *testing.T) {
+	// A dark opaque half beside a white translucent one
+	img := createTestImage(6, 1, color.RGBA64{R: 0x2000, G: 0x2000, B: 0x2000, A: 0xffff})
+	for x := 3; x < 6; x++ {
+		img.Set(x, 0, color.RGBA64{R: 0x8000, G: 0x8000, B: 0x8000, A: 0x8000})
+	}
+	for _, f := range []Filter{Blur{Sigma: 1}, Sharpen{Sigma: 1, Amount: 2}, Emboss{}, Desaturate{}} {
+		out := f.Apply(img)
+		for x := 0; x < 6; x++ {
+			if c := out.RGBA64At(x, 0); c.R > c.A || c.G > c.A || c.B > c.A {
+				t.Errorf("%T: pixel %d = %v has a channel above alpha", f, x, c)
+			}
+		}
+	}
+}
+
+func TestBlurSmoothsEdge(t *testing.T) {
+	img := createTestImage(6, 1, color.Black)
+	for x := 3; x < 6; x++ {
+		img.Set(x, 0, color.White)
+	}
+	out := Blur{Sigma: 1}.Apply(img)
+	left, right := out.RGBA64At(2, 0).R, out.RGBA64At(3, 0).R
+	if left == 0 || right == 0xffff || left >= right {
+		t.Errorf("Edge not smoothed: x=2 %#x, x=3 %#x", left, right)
+	}
+}
+
+func TestSharpenIncreasesContrast(t *testing.T) {
+	img := createTestImage(6, 1, color.RGBA64{R: 0x4000, G: 0x4000, B: 0x4000, A: 0xffff})
+	for x := 3; x < 6; x++ {
+		img.Set(x, 0, color.RGBA64{R: 0xc000, G: 0xc000, B: 0xc000, A: 0xffff})
+	}
+	out := Sharpen{Sigma: 1, Amount: 1}.Apply(img)
+	if out.RGBA64At(2, 0).R >= 0x4000 || out.RGBA64At(3, 0).R <= 0xc000 {
+		t.Errorf("Edge not sharpened: x=2 %#x, x=3 %#x", out.RGBA64At(2, 0).R, out.RGBA64At(3, 0).R)
+	}
+}
+
+func TestVignette(t *testing.T) {
+	img := createTestImage(5, 5, color.White)
+	out := Vignette{Strength: 0.5}.Apply(img)
+	center, corner := out.RGBA64At(2, 2).R, out.RGBA64At(0, 0).R
+	if center != 0xffff {
+		t.Errorf("Center = %#x, want unchanged", center)
+	}
+	if want := uint16(0x7fff); corner < want-1 || corner > want+1 {
+		t.Errorf("Corner = %#x, want about %#x", corner, want)
+	}
+}
+
+func TestPipelineOrder(t *testing.T) {
+	var order []string
+	record := func(name string) Filter {
+		return FilterFunc(func(img *image.RGBA64) *image.RGBA64 {
+			order = append(order, name)
+			return img
+		})
+	}
+	Pipeline{record("a"), nil, record("b"), Pipeline{record("c")}}.Apply(createTestImage(1, 1, color.Black))
+	if len(order) != 3 || order[0] != "a" || order[1] != "b" || order[2] != "c" {
+		t.Errorf("Filters ran in order %v, want [a b c]", order)
+	}
+}
+
+// unregisterFilter removes a filter registered by a test
+func unregisterFilter(name string) {
+	filtersMu.Lock()
+	defer filtersMu.Unlock()
+	delete(filters, name)
+}
+
+func TestFilterRegistry(t *testing.T) {
+	for _, name := range []string{"blur", "sharpen", "emboss", "invert", "desaturate", "vignette"} {
+		if _, err := NewFilter(name); err != nil {
+			t.Errorf("NewFilter(%q) error = %v", name, err)
+		}
+	}
+	if _, err := NewFilter("sepia"); err == nil {
+		t.Error("Expected error for unknown filter")
+	}
+
+	RegisterFilter("test-identity", func() Filter { return Pipeline{} })
+	t.Cleanup(func() { unregisterFilter("test-identity") })
+	found := false
+	for _, name := range FilterNames() {
+		found = found || name == "test-identity"
+	}
+	if !found {
+		t.Error("Registered filter missing from FilterNames()")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic on duplicate registration")
+		}
+	}()
+	RegisterFilter("blur", func() Filter { return Blur{} })
+}
+
+func TestConvertWithFilters(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, createTestImage(4, 2, color.Black)); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	imgPath := writeTestFile(t, buf.Bytes())
+
+	options := ConversionOptions{AspectMode: AspectFixed, FixedWidth: 4, FixedHeight: 2}
+	art, err := Convert(imgPath, options)
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if art.Rows[0] != "@@@@" {
+		t.Errorf("Unfiltered row = %q, want %q", art.Rows[0], "@@@@")
+	}
+
+	options.Filters = []Filter{Invert{}}
+	art, err = Convert(imgPath, options)
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if art.Rows[0] != "...." {
+		t.Errorf("Inverted row = %q, want %q", art.Rows[0], "....")
+	}
+}
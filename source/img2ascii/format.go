@@ -0,0 +1,88 @@
+package img2ascii
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Renderer writes a converted Art in one output format
+type Renderer interface {
+	// ContentType is the MIME type of the rendered output
+	ContentType() string
+	Render(w io.Writer, art *Art) error
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = make(map[string]Renderer)
+)
+
+// RegisterFormat makes a renderer available by name, e.g. to LookupFormat
+// and the server's format field. It panics if name is empty or already
+// registered.
+func RegisterFormat(name string, r Renderer) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	if name == "" || r == nil {
+		panic("img2ascii: RegisterFormat requires a name and renderer")
+	}
+	if _, dup := formats[name]; dup {
+		panic("img2ascii: RegisterFormat called twice for format " + name)
+	}
+	formats[name] = r
+}
+
+// LookupFormat returns the renderer registered under name
+func LookupFormat(name string) (Renderer, error) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	r, ok := formats[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format: %q", name)
+	}
+	return r, nil
+}
+
+// FormatNames returns the names of all registered formats, sorted
+func FormatNames() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterFormat("text", TextRenderer{})
+	RegisterFormat("json", JSONRenderer{})
+	RegisterFormat("sixel", Sixel{Colors: 256, Scale: 4})
+	RegisterFormat("ans", ANSI{})
+	RegisterFormat("irc", IRC{})
+	RegisterFormat("bbcode", BBCode{})
+	RegisterFormat("markdown", Markdown{MaxWidth: 80})
+}
+
+// TextRenderer writes the rows as plain text, as returned by Art.String
+type TextRenderer struct{}
+
+func (TextRenderer) ContentType() string { return "text/plain; charset=utf-8" }
+
+func (TextRenderer) Render(w io.Writer, art *Art) error {
+	_, err := io.WriteString(w, art.String())
+	return err
+}
+
+// JSONRenderer writes the structured form produced by Art.MarshalJSON
+type JSONRenderer struct{}
+
+func (JSONRenderer) ContentType() string { return "application/json; charset=utf-8" }
+
+func (JSONRenderer) Render(w io.Writer, art *Art) error {
+	return json.NewEncoder(w).Encode(art)
+}
@@ -0,0 +1,81 @@
+package img2ascii
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+func TestBuiltinFormats(t *testing.T) {
+	art := &Art{Width: 2, Height: 2, Rows: []string{"@.", ".@"}}
+	tests := []struct {
+		name        string
+		contentType string
+		expected    string
+	}{
+		{"text", "text/plain; charset=utf-8", "@.\n.@\n"},
+		{"json", "application/json; charset=utf-8", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := LookupFormat(tt.name)
+			if err != nil {
+				t.Fatalf("LookupFormat(%q) error = %v", tt.name, err)
+			}
+			if r.ContentType() != tt.contentType {
+				t.Errorf("ContentType() = %q, want %q", r.ContentType(), tt.contentType)
+			}
+			var buf bytes.Buffer
+			if err := r.Render(&buf, art); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if tt.expected != "" && buf.String() != tt.expected {
+				t.Errorf("Render() = %q, want %q", buf.String(), tt.expected)
+			}
+			if tt.name == "json" && !json.Valid(buf.Bytes()) {
+				t.Errorf("Render() produced invalid JSON: %s", buf.String())
+			}
+		})
+	}
+}
+
+type upperRenderer struct{}
+
+func (upperRenderer) ContentType() string { return "text/plain" }
+
+func (upperRenderer) Render(w io.Writer, art *Art) error {
+	_, err := io.WriteString(w, "ART")
+	return err
+}
+
+// unregisterFormat removes a format registered by a test
+func unregisterFormat(name string) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	delete(formats, name)
+}
+
+func TestFormatRegistry(t *testing.T) {
+	if _, err := LookupFormat("xml"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+
+	RegisterFormat("test-upper", upperRenderer{})
+	t.Cleanup(func() { unregisterFormat("test-upper") })
+	found := false
+	for _, name := range FormatNames() {
+		found = found || name == "test-upper"
+	}
+	if !found {
+		t.Error("Registered format missing from FormatNames()")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic on duplicate registration")
+		}
+	}()
+	RegisterFormat("text", TextRenderer{})
+}
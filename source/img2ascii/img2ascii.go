@@ -2,34 +2,83 @@ package img2ascii
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"image"
+	"image/color"
 	imagedraw "image/draw"
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
 	"io"
-	"math"
+	"log/slog"
 	"os"
 	"runtime"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	xdraw "golang.org/x/image/draw"
 )
 
+// Grid bounds for the legacy aspect ratio modes
+const (
+	defaultMaxWidth  = 65
+	defaultMaxHeight = 54
+	maxPixelWidth    = 300
+	maxPixelHeight   = 200
+)
+
 // ASCII character sets for different purposes
 const (
 	defaultASCIIChars = "@#%*o()1l=:-."
 	bannerASCIIChars  = "@#*+=-:. "
 )
 
+// BannerCharacters is the ramp ModeBanner uses when no characters are given
+const BannerCharacters = bannerASCIIChars
+
 // ConversionMode defines how ASCII conversion should be performed
 type ConversionMode int
 
 const (
-	ModeDefault ConversionMode = iota
-	ModeBanner
+	ModeDefault   ConversionMode = iota
+	ModeBanner                   // Ramp tuned for rasterized text
+	ModeThreshold                // Two tones split at a fixed or Otsu threshold
+	ModePosterize                // Luminance quantized to a few evenly spaced levels
+	ModeTextFill                 // The image's shape drawn with FillText in reading order
+	ModeEmoji                    // Each cell's colour matched to the nearest emoji in Palette
 )
 
+func (m ConversionMode) String() string {
+	switch m {
+	case ModeDefault:
+		return "default"
+	case ModeBanner:
+		return "banner"
+	case ModeThreshold:
+		return "threshold"
+	case ModePosterize:
+		return "posterize"
+	case ModeTextFill:
+		return "textfill"
+	case ModeEmoji:
+		return "emoji"
+	default:
+		return fmt.Sprintf("ConversionMode(%d)", int(m))
+	}
+}
+
+// ParseConversionMode returns the mode named by String
+func ParseConversionMode(name string) (ConversionMode, error) {
+	for m := ModeDefault; m <= ModeEmoji; m++ {
+		if m.String() == name {
+			return m, nil
+		}
+	}
+	return ModeDefault, fmt.Errorf("unknown conversion mode: %q", name)
+}
+
 // AspectRatioMode defines how aspect ratio should be handled
 type AspectRatioMode int
 
@@ -39,12 +88,129 @@ const (
 	AspectFixed                        // Fixed output size, ignore aspect ratio
 )
 
+func (a AspectRatioMode) String() string {
+	switch a {
+	case AspectScale:
+		return "scale"
+	case AspectPixel:
+		return "pixel"
+	case AspectFixed:
+		return "fixed"
+	default:
+		return fmt.Sprintf("AspectRatioMode(%d)", int(a))
+	}
+}
+
 type ConversionOptions struct {
 	AspectMode  AspectRatioMode
-	FixedWidth  int
+	FixedWidth  int // AspectFixed size; if either is 0 the source size is used
 	FixedHeight int
 	Reverse     bool
 	Mode        ConversionMode
+	Luminance   LuminanceModel
+	Characters  string   // custom ramp, darkest first; empty uses the mode's default
+	Threshold   int      // ModeThreshold and ModeTextFill cut-off in 1-255; 0 picks one with Otsu's method
+	Levels      int      // ModePosterize level count; 0 means 4
+	Filters     []Filter // applied in order to the resampled image
+	Layout      Layout   // overrides AspectMode, FixedWidth and FixedHeight when set
+
+	// FillText is repeated in reading order over the ink cells in
+	// ModeTextFill; empty uses "img2ascii". Which side of the threshold is
+	// ink follows the background, like the dense end of a ramp. FillAll
+	// draws every cell instead, leaving brightness to the per-cell Colors.
+	FillText string
+	FillAll  bool
+
+	// Palette is the set of emoji used by ModeEmoji; the zero value uses
+	// EmojiSquares. Emoji are two columns wide, so the layout's width and
+	// budget are halved to keep the output the same width in columns.
+	Palette EmojiPalette
+
+	// TargetBackground picks the ramp direction for the display surface;
+	// when unset, Reverse is used as given
+	TargetBackground Background
+	Limits           Limits       // zero value means DefaultLimits
+	Logger           *slog.Logger // optional; nil disables logging
+
+	// Per-cell data to include in the resulting Art
+	IncludeColors    bool
+	IncludeLuminance bool
+}
+
+// Limits bounds the dimensions an image may declare in its header. They are
+// checked with image.DecodeConfig before any pixel data is decoded, so a small
+// file claiming a huge canvas is rejected without allocating it. A field <= 0
+// leaves that dimension unbounded.
+type Limits struct {
+	MaxWidth  int
+	MaxHeight int
+	MaxPixels int64
+}
+
+// DefaultLimits keeps the decoded 8-bit buffer of a single image under
+// ~128 MiB. Images are resampled to the output grid straight from the
+// decoded data, so no full-size 16-bit copy is made.
+var DefaultLimits = Limits{
+	MaxWidth:  8192,
+	MaxHeight: 8192,
+	MaxPixels: 32 << 20,
+}
+
+// ImageTooLargeError is returned when an image's declared dimensions exceed
+// the configured Limits.
+type ImageTooLargeError struct {
+	Width  int
+	Height int
+	Limits Limits
+}
+
+func (e *ImageTooLargeError) Error() string {
+	return fmt.Sprintf("image dimensions %dx%d exceed limits (max %dx%d, %d pixels)",
+		e.Width, e.Height, e.Limits.MaxWidth, e.Limits.MaxHeight, e.Limits.MaxPixels)
+}
+
+// IsImageTooLarge reports whether err is, or wraps, an *ImageTooLargeError.
+func IsImageTooLarge(err error) bool {
+	var tooLarge *ImageTooLargeError
+	return errors.As(err, &tooLarge)
+}
+
+func (l Limits) orDefault() Limits {
+	if l == (Limits{}) {
+		return DefaultLimits
+	}
+	return l
+}
+
+// Check returns an *ImageTooLargeError if width x height is outside the limits.
+func (l Limits) Check(width, height int) error {
+	if (l.MaxWidth > 0 && width > l.MaxWidth) ||
+		(l.MaxHeight > 0 && height > l.MaxHeight) ||
+		(l.MaxPixels > 0 && int64(width)*int64(height) > l.MaxPixels) {
+		return &ImageTooLargeError{Width: width, Height: height, Limits: l}
+	}
+	return nil
+}
+
+// decodeConfig reads only the image header and validates it against limits.
+func decodeConfig(r io.Reader, limits Limits) (image.Config, error) {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	if err := limits.Check(cfg.Width, cfg.Height); err != nil {
+		return image.Config{}, err
+	}
+	return cfg, nil
+}
+
+func decodeConfigFile(imgPath string, limits Limits) (image.Config, error) {
+	file, err := os.Open(imgPath)
+	if err != nil {
+		return image.Config{}, err
+	}
+	defer file.Close()
+	return decodeConfig(file, limits)
 }
 
 type Resolution struct {
@@ -55,56 +221,32 @@ type Resolution struct {
 type Image struct {
 	Name string
 	Res  Resolution
-	Data []byte
+	Data []byte // image.RGBA64 Pix layout: 16-bit big-endian R, G, B, A per pixel
 }
 
 func Run(reverse bool, imgPath string, outputPath string) error {
-	file, err := os.Open(imgPath)
+	art, err := Convert(imgPath, ConversionOptions{Reverse: reverse})
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-	img, _, err := image.Decode(file)
-	if err != nil {
-		return err
-	}
-	bounds := img.Bounds()
-	origWidth := bounds.Dx()
-	origHeight := bounds.Dy()
-	maxWidth := 65
-	maxHeight := 54
-	var targetWidth, targetHeight int
-	imgAspect := float64(origWidth) / float64(origHeight)
-	pageAspect := float64(maxWidth) / float64(maxHeight)
-	if imgAspect > pageAspect {
-		targetWidth = maxWidth
-		targetHeight = int(float64(maxWidth) / imgAspect)
-		if targetHeight > maxHeight {
-			targetHeight = maxHeight
-		}
-	} else {
-		targetHeight = maxHeight
-		targetWidth = int(float64(maxHeight) * imgAspect)
-		if targetWidth > maxWidth {
-			targetWidth = maxWidth
-		}
-	}
-	imgObj, err := newImage(imgPath, targetWidth, targetHeight)
+	return writeArt(outputPath, art, TextRenderer{}, 0700)
+}
+
+func writeArt(outputPath string, art *Art, r Renderer, perm os.FileMode) error {
+	fileOut, err := os.OpenFile(outputPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
 	if err != nil {
 		return err
 	}
-	asciiArt := imgObj.toASCII(ModeDefault, reverse)
-	fileOut, err := os.OpenFile(outputPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0700)
-	if err != nil {
+	if err := r.Render(fileOut, art); err != nil {
+		fileOut.Close()
 		return err
 	}
-	defer fileOut.Close()
-	_, err = fileOut.WriteString(asciiArt)
-	_ = os.WriteFile("img2ascii.log", []byte(asciiArt), 0644)
-	return err
+	return fileOut.Close()
 }
 
-func newImage(imgPath string, targetWidth, targetHeight int) (*Image, error) {
+// newImage decodes imgPath and resamples the placement's crop region to its
+// grid size. A zero Placement keeps the image at its original size.
+func newImage(imgPath string, placement Placement, limits Limits, filters Pipeline) (*Image, error) {
 	file, err := os.Open(imgPath)
 	if err != nil {
 		return nil, err
@@ -114,25 +256,48 @@ func newImage(imgPath string, targetWidth, targetHeight int) (*Image, error) {
 	if err != nil {
 		return nil, err
 	}
+	if _, err := decodeConfig(bytes.NewReader(d), limits); err != nil {
+		return nil, err
+	}
 	img, _, err := image.Decode(bytes.NewReader(d))
 	if err != nil {
 		return nil, err
 	}
+	i := imageFrom(img, placement, filters)
+	i.Name = imgPath
+	return i, nil
+}
+
+// imageFrom resamples the placement's crop region of a decoded image to its
+// grid size
+func imageFrom(img image.Image, placement Placement, filters Pipeline) *Image {
 	bounds := img.Bounds()
-	rgbaImg := image.NewRGBA(bounds)
-	imagedraw.Draw(rgbaImg, bounds, img, bounds.Min, imagedraw.Src)
-	width := bounds.Dx()
-	height := bounds.Dy()
-	if targetWidth > 0 && targetHeight > 0 {
-		rgbaImg = resizeRGBA(rgbaImg, targetWidth, targetHeight)
-		width = targetWidth
-		height = targetHeight
+	crop := placement.Crop
+	if crop.Empty() {
+		crop = image.Rect(0, 0, bounds.Dx(), bounds.Dy())
+	}
+	// Keep 16 bits per channel so 16-bit PNGs retain their precision.
+	// Resample from the decoded image directly rather than from a
+	// full-size 16-bit copy, which would double the memory of large images.
+	var rgbaImg *image.RGBA64
+	width := crop.Dx()
+	height := crop.Dy()
+	if placement.Width > 0 && placement.Height > 0 {
+		width = placement.Width
+		height = placement.Height
+		rgbaImg = image.NewRGBA64(image.Rect(0, 0, width, height))
+		xdraw.ApproxBiLinear.Scale(rgbaImg, rgbaImg.Bounds(), img, crop.Add(bounds.Min), xdraw.Over, nil)
+	} else {
+		rgbaImg = image.NewRGBA64(image.Rect(0, 0, width, height))
+		imagedraw.Draw(rgbaImg, rgbaImg.Bounds(), img, bounds.Min.Add(crop.Min), imagedraw.Src)
+	}
+	if len(filters) > 0 {
+		rgbaImg = filters.Apply(rgbaImg)
 	}
 	return &Image{
-		Name: imgPath,
 		Res:  Resolution{Width: width, Height: height},
 		Data: rgbaImg.Pix,
-	}, nil
+	}
 }
 
 type Pixel struct {
@@ -146,12 +311,26 @@ func (r Resolution) pixelCount() int {
 	return r.Width * r.Height
 }
 
+// calculateLuminance returns the Rec.709 luma of an 8-bit sRGB colour
 func calculateLuminance(r, g, b int) int {
-	l := 0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(b)
-	return int(math.Round(l))
+	return luminanceScore(LumRec709, uint16(r*0x101), uint16(g*0x101), uint16(b*0x101))
+}
+
+// pixel returns the 16-bit colour at index idx, or ok == false if Data is short
+func (i Image) pixel(idx int) (r, g, b, a uint16, ok bool) {
+	off := idx * 8
+	if off+7 >= len(i.Data) {
+		return 0, 0, 0, 0, false
+	}
+	p := i.Data[off : off+8 : off+8]
+	r = uint16(p[0])<<8 | uint16(p[1])
+	g = uint16(p[2])<<8 | uint16(p[3])
+	b = uint16(p[4])<<8 | uint16(p[5])
+	a = uint16(p[6])<<8 | uint16(p[7])
+	return r, g, b, a, true
 }
 
-func (i Image) toLumScores() []int {
+func (i Image) toLumScores(model LuminanceModel) []int {
 	pixelCount := i.Res.pixelCount()
 	lScores := make([]int, pixelCount)
 	workers := runtime.NumCPU()
@@ -163,9 +342,8 @@ func (i Image) toLumScores() []int {
 	for w := 0; w < workers; w++ {
 		go func() {
 			for idx := range tasks {
-				byteIdx := idx * 4
-				if byteIdx+3 < len(i.Data) {
-					lScores[idx] = calculateLuminance(int(i.Data[byteIdx]), int(i.Data[byteIdx+1]), int(i.Data[byteIdx+2]))
+				if r, g, b, _, ok := i.pixel(idx); ok {
+					lScores[idx] = luminanceScore(model, r, g, b)
 				}
 			}
 			done <- struct{}{}
@@ -189,10 +367,14 @@ func reverseString(s string) string {
 	return string(runes)
 }
 
-func makeASCII(mode ConversionMode, reverse bool, luminance int) string {
-	var ascii string
-	switch mode {
-	case ModeBanner:
+// rampFor returns the character ramp, darkest first, used for mode
+func rampFor(mode ConversionMode, reverse bool, characters string) string {
+	ascii := characters
+	switch {
+	case mode == ModeTextFill:
+		ascii = textFillRamp
+	case ascii != "":
+	case mode == ModeBanner:
 		ascii = bannerASCIIChars
 	default:
 		ascii = defaultASCIIChars
@@ -201,154 +383,210 @@ func makeASCII(mode ConversionMode, reverse bool, luminance int) string {
 	if reverse {
 		ascii = reverseString(ascii)
 	}
+	return ascii
+}
+
+func makeASCII(mode ConversionMode, reverse bool, luminance int) string {
+	return string(rampChar([]rune(rampFor(mode, reverse, "")), luminance))
+}
 
+func rampChar(ascii []rune, luminance int) rune {
 	idx := luminance * (len(ascii) - 1) / 255
 	if idx >= len(ascii) {
 		idx = len(ascii) - 1
 	}
-	return string(ascii[idx])
+	return ascii[idx]
 }
 
 func (i Image) toASCII(mode ConversionMode, reverse bool) string {
-	lScores := i.toLumScores()
-	var asciiArt strings.Builder
-	asciiArt.Grow(i.Res.Width * (i.Res.Height + 1))
+	return i.toArt(ConversionOptions{Mode: mode, Reverse: reverse}).String()
+}
+
+func (i Image) toArt(options ConversionOptions) *Art {
+	lScores := i.toLumScores(options.Luminance)
+	background, reverse := resolveBackground(options, lScores, i.Res.Width, i.Res.Height)
+	ramp := []rune(rampFor(options.Mode, reverse, options.Characters))
+	mapper := newCellMapper(options, ramp, lScores)
+	art := &Art{
+		Width:      i.Res.Width,
+		Height:     i.Res.Height,
+		Rows:       make([]string, i.Res.Height),
+		Ramp:       string(mapper.ramp),
+		Threshold:  mapper.threshold,
+		Background: background,
+		Source:     i.Res,
+		Options:    options,
+		raster:     i.rgba64(),
+	}
+	var emoji *emojiMapper
+	if options.Mode == ModeEmoji {
+		m := newEmojiMapper(options.Palette)
+		emoji = &m
+		art.Ramp = m.ramp()
+	}
+	if options.IncludeColors {
+		art.Colors = make([][]color.RGBA, i.Res.Height)
+	}
+	if options.IncludeLuminance {
+		art.Luminance = make([][]int, i.Res.Height)
+	}
+	var row strings.Builder
+	row.Grow(i.Res.Width * utf8.UTFMax)
 	for j := 0; j < i.Res.Height; j++ {
+		row.Reset()
 		for k := 0; k < i.Res.Width; k++ {
 			idx := j*i.Res.Width + k
-			if idx < len(lScores) {
-				asciiArt.WriteString(makeASCII(mode, reverse, lScores[idx]))
+			if emoji != nil {
+				if r, g, b, _, ok := i.pixel(idx); ok {
+					row.WriteString(emoji.emoji(r, g, b))
+				}
+			} else if idx < len(lScores) {
+				row.WriteRune(mapper.char(lScores[idx]))
 			}
 		}
-		asciiArt.WriteByte('\n')
+		art.Rows[j] = row.String()
+		if art.Colors != nil {
+			art.Colors[j] = i.rowColors(j)
+		}
+		if art.Luminance != nil {
+			art.Luminance[j] = lScores[j*i.Res.Width : (j+1)*i.Res.Width]
+		}
+	}
+	return art
+}
+
+// rgba64 wraps the pixel data as an image without copying it
+func (i Image) rgba64() *image.RGBA64 {
+	return &image.RGBA64{
+		Pix:    i.Data,
+		Stride: 8 * i.Res.Width,
+		Rect:   image.Rect(0, 0, i.Res.Width, i.Res.Height),
+	}
+}
+
+func (i Image) rowColors(y int) []color.RGBA {
+	colors := make([]color.RGBA, i.Res.Width)
+	for x := range colors {
+		if r, g, b, a, ok := i.pixel(y*i.Res.Width + x); ok {
+			colors[x] = color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: uint8(a >> 8)}
+		}
 	}
-	return asciiArt.String()
+	return colors
 }
 
-func resizeRGBA(src *image.RGBA, targetWidth, targetHeight int) *image.RGBA {
-	dst := image.NewRGBA(image.Rect(0, 0, targetWidth, targetHeight))
+func resizeRGBA64(src *image.RGBA64, targetWidth, targetHeight int) *image.RGBA64 {
+	dst := image.NewRGBA64(image.Rect(0, 0, targetWidth, targetHeight))
 	xdraw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, src.Bounds(), xdraw.Over, nil)
 	return dst
 }
 
 func RunBanner(imgPath string, outputPath string, width, height int) error {
-	file, err := os.Open(imgPath)
+	art, err := Convert(imgPath, ConversionOptions{
+		Mode:   ModeBanner,
+		Layout: Layout{Fit: FitContain, Width: width, Height: height},
+	})
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-	img, _, err := image.Decode(file)
-	if err != nil {
-		return err
+	return writeArt(outputPath, art, TextRenderer{}, 0644)
+}
+
+// layout returns the explicit Layout, or the one implied by AspectMode for
+// a source of the given size
+func (o ConversionOptions) layout(srcWidth, srcHeight int) Layout {
+	if o.Layout != (Layout{}) {
+		return o.Layout
 	}
-	bounds := img.Bounds()
-	origWidth := bounds.Dx()
-	origHeight := bounds.Dy()
-	maxWidth := width
-	maxHeight := height
-	var targetWidth, targetHeight int
-	imgAspect := float64(origWidth) / float64(origHeight)
-	pageAspect := float64(maxWidth) / float64(maxHeight)
-	if imgAspect > pageAspect {
-		targetWidth = maxWidth
-		targetHeight = int(float64(maxWidth) / imgAspect)
-		if targetHeight > maxHeight {
-			targetHeight = maxHeight
-		}
-	} else {
-		targetHeight = maxHeight
-		targetWidth = int(float64(maxHeight) * imgAspect)
-		if targetWidth > maxWidth {
-			targetWidth = maxWidth
+	switch o.AspectMode {
+	case AspectPixel:
+		// Cap 1:1 output to prevent browser crashes with very large images
+		return Layout{Fit: FitContain, Width: maxPixelWidth, Height: maxPixelHeight, NoUpscale: true}
+	case AspectFixed:
+		if o.FixedWidth <= 0 || o.FixedHeight <= 0 {
+			return Layout{Fit: FitStretch, Width: srcWidth, Height: srcHeight}
 		}
+		return Layout{Fit: FitStretch, Width: o.FixedWidth, Height: o.FixedHeight}
+	default:
+		return Layout{Fit: FitContain, Width: defaultMaxWidth, Height: defaultMaxHeight}
 	}
+}
 
-	imgObj, err := newImage(imgPath, targetWidth, targetHeight)
+// Convert converts the image at imgPath and returns the structured result
+func Convert(imgPath string, options ConversionOptions) (*Art, error) {
+	cfg, err := decodeConfigFile(imgPath, options.Limits.orDefault())
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return convert(options, cfg.Width, cfg.Height, func(placement Placement) (*Image, error) {
+		return newImage(imgPath, placement, options.Limits.orDefault(), options.Filters)
+	})
+}
 
-	asciiArt := imgObj.toASCII(ModeBanner, false)
-	fileOut, err := os.OpenFile(outputPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
-	if err != nil {
-		return err
+// ConvertImage converts an image that is already in memory, such as one
+// drawn by the banner renderer
+func ConvertImage(img image.Image, options ConversionOptions) (*Art, error) {
+	bounds := img.Bounds()
+	if err := options.Limits.orDefault().Check(bounds.Dx(), bounds.Dy()); err != nil {
+		return nil, err
 	}
-	defer fileOut.Close()
-	_, err = fileOut.WriteString(asciiArt)
-	_ = os.WriteFile("img2ascii.log", []byte(asciiArt), 0644)
-	return err
+	return convert(options, bounds.Dx(), bounds.Dy(), func(placement Placement) (*Image, error) {
+		return imageFrom(img, placement, options.Filters), nil
+	})
 }
 
-func RunWithOptions(imgPath string, outputPath string, options ConversionOptions) error {
-	file, err := os.Open(imgPath)
-	if err != nil {
-		return err
+// convert places a source image of the given size, loads it resampled to
+// the placement and maps it to characters
+func convert(options ConversionOptions, origWidth, origHeight int, load func(Placement) (*Image, error)) (*Art, error) {
+	start := time.Now()
+	layout := options.layout(origWidth, origHeight)
+	if options.Mode == ModeEmoji {
+		layout = layout.wideCells()
 	}
-	defer file.Close()
-	img, _, err := image.Decode(file)
+	placement, err := layout.Place(origWidth, origHeight)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	bounds := img.Bounds()
-	origWidth := bounds.Dx()
-	origHeight := bounds.Dy()
-
-	var targetWidth, targetHeight int
 
-	switch options.AspectMode {
-	case AspectPixel:
-		// 1:1 pixel mapping - use original dimensions with reasonable limits
-		targetWidth = origWidth
-		targetHeight = origHeight
-		// Limit to prevent browser crashes with very large images
-		maxPixelWidth := 300
-		maxPixelHeight := 200
-		if targetWidth > maxPixelWidth {
-			ratio := float64(maxPixelWidth) / float64(targetWidth)
-			targetWidth = maxPixelWidth
-			targetHeight = int(float64(targetHeight) * ratio)
-		}
-		if targetHeight > maxPixelHeight {
-			ratio := float64(maxPixelHeight) / float64(targetHeight)
-			targetHeight = maxPixelHeight
-			targetWidth = int(float64(targetWidth) * ratio)
-		}
-	case AspectFixed:
-		// Fixed output size - use specified dimensions
-		targetWidth = options.FixedWidth
-		targetHeight = options.FixedHeight
-	default: // AspectScale
-		// Scale maintaining aspect ratio (original behavior)
-		maxWidth := 65
-		maxHeight := 54
-		imgAspect := float64(origWidth) / float64(origHeight)
-		pageAspect := float64(maxWidth) / float64(maxHeight)
-		if imgAspect > pageAspect {
-			targetWidth = maxWidth
-			targetHeight = int(float64(maxWidth) / imgAspect)
-			if targetHeight > maxHeight {
-				targetHeight = maxHeight
-			}
-		} else {
-			targetHeight = maxHeight
-			targetWidth = int(float64(maxHeight) * imgAspect)
-			if targetWidth > maxWidth {
-				targetWidth = maxWidth
-			}
-		}
+	imgObj, err := load(placement)
+	if err != nil {
+		return nil, err
+	}
+	art := imgObj.toArt(options)
+	art.Source = Resolution{Width: origWidth, Height: origHeight}
+	if options.Logger != nil {
+		options.Logger.Info("image converted",
+			"source_width", origWidth,
+			"source_height", origHeight,
+			"width", placement.Width,
+			"height", placement.Height,
+			"fit", layout.Fit.String(),
+			"mode", options.Mode.String(),
+			"threshold", art.Threshold,
+			"luminance", options.Luminance.String(),
+			"filters", len(options.Filters),
+			"aspect_mode", options.AspectMode.String(),
+			"reverse", options.Reverse,
+			"background", art.Background.String(),
+			"duration", time.Since(start),
+		)
 	}
+	return art, nil
+}
 
-	imgObj, err := newImage(imgPath, targetWidth, targetHeight)
+func RunWithOptions(imgPath string, outputPath string, options ConversionOptions) error {
+	art, err := Convert(imgPath, options)
 	if err != nil {
 		return err
 	}
-	asciiArt := imgObj.toASCII(options.Mode, options.Reverse)
-	fileOut, err := os.OpenFile(outputPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0700)
+	return writeArt(outputPath, art, TextRenderer{}, 0700)
+}
+
+// RunFormat converts imgPath and writes it to outputPath with the given
+// renderer, e.g. ANSI{Title: "..."} for an .ans file
+func RunFormat(imgPath string, outputPath string, options ConversionOptions, r Renderer) error {
+	art, err := Convert(imgPath, options)
 	if err != nil {
 		return err
 	}
-	defer fileOut.Close()
-	_, err = fileOut.WriteString(asciiArt)
-	_ = os.WriteFile("img2ascii.log", []byte(asciiArt), 0644)
-	return err
+	return writeArt(outputPath, art, r, 0644)
 }
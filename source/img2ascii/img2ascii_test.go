@@ -1,8 +1,17 @@
 package img2ascii
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
 	"image"
 	"image/color"
+	"image/png"
+	"log/slog"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -105,8 +114,8 @@ func TestResolution_pixelCount(t *testing.T) {
 	}
 }
 
-func createTestImage(width, height int, fillColor color.RGBA) *image.RGBA {
-	img := image.NewRGBA(image.Rect(0, 0, width, height))
+func createTestImage(width, height int, fillColor color.Color) *image.RGBA64 {
+	img := image.NewRGBA64(image.Rect(0, 0, width, height))
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
 			img.Set(x, y, fillColor)
@@ -126,7 +135,7 @@ func TestImage_toLumScores(t *testing.T) {
 		Data: testImg.Pix,
 	}
 
-	scores := img.toLumScores()
+	scores := img.toLumScores(LumRec709)
 
 	if len(scores) != width*height {
 		t.Errorf("Expected %d luminance scores, got %d", width*height, len(scores))
@@ -186,6 +195,198 @@ func TestRunBannerWithInvalidFile(t *testing.T) {
 	}
 }
 
+// hugePNG encodes a 1x1 PNG and rewrites its IHDR chunk to declare
+// width x height, producing a tiny file that claims a huge canvas.
+func hugePNG(t *testing.T, width, height uint32) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, createTestImage(1, 1, color.RGBA{A: 255})); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	data := buf.Bytes()
+	// 8-byte signature, 4-byte length, then "IHDR" and its 13-byte payload.
+	ihdr := data[12 : 12+4+13]
+	binary.BigEndian.PutUint32(ihdr[4:8], width)
+	binary.BigEndian.PutUint32(ihdr[8:12], height)
+	binary.BigEndian.PutUint32(data[12+4+13:], crc32.ChecksumIEEE(ihdr))
+	return data
+}
+
+func writeTestFile(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "input.png")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	return path
+}
+
+func TestLimitsCheck(t *testing.T) {
+	limits := Limits{MaxWidth: 100, MaxHeight: 50, MaxPixels: 2000}
+	tests := []struct {
+		name          string
+		width, height int
+		tooLarge      bool
+	}{
+		{"Within limits", 40, 40, false},
+		{"At limits", 40, 50, false},
+		{"Too wide", 101, 1, true},
+		{"Too tall", 1, 51, true},
+		{"Too many pixels", 100, 21, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limits.Check(tt.width, tt.height)
+			if IsImageTooLarge(err) != tt.tooLarge {
+				t.Errorf("Check(%d, %d) = %v, tooLarge want %v", tt.width, tt.height, err, tt.tooLarge)
+			}
+		})
+	}
+
+	if err := (Limits{}).Check(1<<20, 1<<20); err != nil {
+		t.Errorf("Zero limits should be unbounded, got %v", err)
+	}
+	// The default admits images up to 32 megapixels
+	if err := DefaultLimits.Check(8000, 4000); err != nil {
+		t.Errorf("DefaultLimits rejected 32 megapixels: %v", err)
+	}
+	if err := DefaultLimits.Check(8192, 4097); !IsImageTooLarge(err) {
+		t.Errorf("DefaultLimits accepted more than 32 megapixels")
+	}
+}
+
+func TestDecompressionBomb(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height uint32
+	}{
+		{"Huge square", 30000, 30000},
+		{"Huge width", 100000, 1},
+		{"Huge height", 1, 100000},
+		{"Too many pixels", 8000, 8000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imgPath := writeTestFile(t, hugePNG(t, tt.width, tt.height))
+			outPath := filepath.Join(t.TempDir(), "output.txt")
+
+			err := RunWithOptions(imgPath, outPath, ConversionOptions{})
+			var tooLarge *ImageTooLargeError
+			if !errors.As(err, &tooLarge) {
+				t.Fatalf("RunWithOptions() error = %v, want *ImageTooLargeError", err)
+			}
+			if tooLarge.Width != int(tt.width) || tooLarge.Height != int(tt.height) {
+				t.Errorf("Error reports %dx%d, want %dx%d", tooLarge.Width, tooLarge.Height, tt.width, tt.height)
+			}
+
+			if err := Run(false, imgPath, outPath); !IsImageTooLarge(err) {
+				t.Errorf("Run() error = %v, want *ImageTooLargeError", err)
+			}
+		})
+	}
+}
+
+func TestRunWithOptionsCustomLimits(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, createTestImage(20, 20, color.RGBA{A: 255})); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	imgPath := writeTestFile(t, buf.Bytes())
+	outPath := filepath.Join(t.TempDir(), "output.txt")
+
+	if err := RunWithOptions(imgPath, outPath, ConversionOptions{}); err != nil {
+		t.Fatalf("Default limits should accept 20x20 image: %v", err)
+	}
+
+	options := ConversionOptions{Limits: Limits{MaxPixels: 399}}
+	if err := RunWithOptions(imgPath, outPath, options); !IsImageTooLarge(err) {
+		t.Errorf("RunWithOptions() error = %v, want *ImageTooLargeError", err)
+	}
+}
+
+func TestConvertImage(t *testing.T) {
+	img := createTestImage(16, 8, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			img.Set(x, y, color.RGBA{A: 255})
+		}
+	}
+	art, err := ConvertImage(img, ConversionOptions{
+		Mode:   ModeBanner,
+		Layout: Layout{Fit: FitStretch, Width: 4, Height: 2},
+	})
+	if err != nil {
+		t.Fatalf("ConvertImage() error = %v", err)
+	}
+	if len(art.Rows) != 2 || art.Rows[0] != "@@  " || art.Source != (Resolution{Width: 16, Height: 8}) {
+		t.Errorf("ConvertImage() = %q from %+v", art.Rows, art.Source)
+	}
+
+	if _, err := ConvertImage(img, ConversionOptions{Limits: Limits{MaxPixels: 100}}); !IsImageTooLarge(err) {
+		t.Errorf("ConvertImage() error = %v, want *ImageTooLargeError", err)
+	}
+}
+
+func TestRunWithOptionsLogging(t *testing.T) {
+	var img bytes.Buffer
+	if err := png.Encode(&img, createTestImage(20, 10, color.RGBA{A: 255})); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	imgPath := writeTestFile(t, img.Bytes())
+	dir := t.TempDir()
+	outPath := filepath.Join(dir, "output.txt")
+
+	// Run from an empty directory to catch files written relative to the cwd.
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	var buf bytes.Buffer
+	options := ConversionOptions{
+		AspectMode:  AspectFixed,
+		FixedWidth:  8,
+		FixedHeight: 4,
+		Logger:      slog.New(slog.NewJSONHandler(&buf, nil)),
+	}
+	if err := RunWithOptions(imgPath, outPath, options); err != nil {
+		t.Fatalf("RunWithOptions() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "output.txt" {
+		t.Errorf("Expected only output.txt to be written, found %v", entries)
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Failed to parse log record %q: %v", buf.String(), err)
+	}
+	expected := map[string]any{
+		"msg":           "image converted",
+		"source_width":  float64(20),
+		"source_height": float64(10),
+		"width":         float64(8),
+		"height":        float64(4),
+		"mode":          "default",
+		"aspect_mode":   "fixed",
+	}
+	for k, v := range expected {
+		if record[k] != v {
+			t.Errorf("Log field %q = %v, want %v", k, record[k], v)
+		}
+	}
+}
+
 // Integration test with a real small image
 func TestRunIntegration(t *testing.T) {
 	// Skip integration test for now as it requires more setup
@@ -0,0 +1,213 @@
+package img2ascii
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+)
+
+// Fit selects how a source image is fitted to the output grid
+type Fit int
+
+const (
+	FitContain Fit = iota // Largest size within Width x Height keeping the aspect ratio
+	FitCover              // Exactly Width x Height, cropping the source to keep the aspect ratio
+	FitStretch            // Exactly Width x Height, ignoring the aspect ratio
+	FitWidth              // Exactly Width columns; the height follows the aspect ratio
+	FitHeight             // Exactly Height rows; the width follows the aspect ratio
+	FitBudget             // Keep the aspect ratio using at most Budget cells in total
+)
+
+func (f Fit) String() string {
+	switch f {
+	case FitContain:
+		return "contain"
+	case FitCover:
+		return "cover"
+	case FitStretch:
+		return "stretch"
+	case FitWidth:
+		return "width"
+	case FitHeight:
+		return "height"
+	case FitBudget:
+		return "budget"
+	default:
+		return fmt.Sprintf("Fit(%d)", int(f))
+	}
+}
+
+// ParseFit returns the fit mode named by String
+func ParseFit(name string) (Fit, error) {
+	for f := FitContain; f <= FitBudget; f++ {
+		if f.String() == name {
+			return f, nil
+		}
+	}
+	return FitContain, fmt.Errorf("unknown fit mode: %q", name)
+}
+
+// Layout describes the grid an image is fitted into. Width and Height are
+// the grid bounds; for FitWidth and FitHeight the other bound is optional
+// and, if set, caps the derived dimension while keeping the aspect ratio.
+// For FitBudget both bounds are optional caps.
+type Layout struct {
+	Fit    Fit
+	Width  int
+	Height int
+	Budget int // FitBudget only: maximum Width*Height
+
+	// NoUpscale keeps sources smaller than the bounds at their own size
+	// (one cell per pixel). It applies to FitContain and FitBudget.
+	NoUpscale bool
+}
+
+// Placement is the result of fitting a source image to a Layout
+type Placement struct {
+	Width  int
+	Height int
+	Crop   image.Rectangle // region of the source to sample, from (0, 0)
+}
+
+var errInvalidLayout = errors.New("invalid layout")
+
+// Place fits a srcWidth x srcHeight image to the layout. The resulting grid
+// is always at least 1x1.
+func (l Layout) Place(srcWidth, srcHeight int) (Placement, error) {
+	if srcWidth <= 0 || srcHeight <= 0 {
+		return Placement{}, fmt.Errorf("%w: source size %dx%d", errInvalidLayout, srcWidth, srcHeight)
+	}
+	if l.Width < 0 || l.Height < 0 || l.Budget < 0 {
+		return Placement{}, fmt.Errorf("%w: negative bound", errInvalidLayout)
+	}
+	full := image.Rect(0, 0, srcWidth, srcHeight)
+	aspect := float64(srcWidth) / float64(srcHeight)
+
+	switch l.Fit {
+	case FitContain:
+		if l.Width == 0 || l.Height == 0 {
+			return Placement{}, fmt.Errorf("%w: contain requires width and height", errInvalidLayout)
+		}
+		if l.NoUpscale && srcWidth <= l.Width && srcHeight <= l.Height {
+			return Placement{Width: srcWidth, Height: srcHeight, Crop: full}, nil
+		}
+		w, h := containIn(aspect, l.Width, l.Height)
+		return Placement{Width: w, Height: h, Crop: full}, nil
+
+	case FitCover:
+		if l.Width == 0 || l.Height == 0 {
+			return Placement{}, fmt.Errorf("%w: cover requires width and height", errInvalidLayout)
+		}
+		return Placement{Width: l.Width, Height: l.Height, Crop: coverCrop(srcWidth, srcHeight, l.Width, l.Height)}, nil
+
+	case FitStretch:
+		if l.Width == 0 || l.Height == 0 {
+			return Placement{}, fmt.Errorf("%w: stretch requires width and height", errInvalidLayout)
+		}
+		return Placement{Width: l.Width, Height: l.Height, Crop: full}, nil
+
+	case FitWidth:
+		if l.Width == 0 {
+			return Placement{}, fmt.Errorf("%w: width fit requires width", errInvalidLayout)
+		}
+		w, h := l.Width, atLeastOne(float64(l.Width)/aspect)
+		if l.Height > 0 && h > l.Height {
+			w, h = containIn(aspect, l.Width, l.Height)
+		}
+		return Placement{Width: w, Height: h, Crop: full}, nil
+
+	case FitHeight:
+		if l.Height == 0 {
+			return Placement{}, fmt.Errorf("%w: height fit requires height", errInvalidLayout)
+		}
+		w, h := atLeastOne(float64(l.Height)*aspect), l.Height
+		if l.Width > 0 && w > l.Width {
+			w, h = containIn(aspect, l.Width, l.Height)
+		}
+		return Placement{Width: w, Height: h, Crop: full}, nil
+
+	case FitBudget:
+		if l.Budget == 0 {
+			return Placement{}, fmt.Errorf("%w: budget fit requires budget", errInvalidLayout)
+		}
+		if l.NoUpscale && srcWidth*srcHeight <= l.Budget &&
+			(l.Width == 0 || srcWidth <= l.Width) && (l.Height == 0 || srcHeight <= l.Height) {
+			return Placement{Width: srcWidth, Height: srcHeight, Crop: full}, nil
+		}
+		w, h := budgetFit(aspect, l.Budget)
+		if (l.Width > 0 && w > l.Width) || (l.Height > 0 && h > l.Height) {
+			maxW, maxH := l.Width, l.Height
+			if maxW == 0 {
+				maxW = w
+			}
+			if maxH == 0 {
+				maxH = h
+			}
+			w, h = containIn(aspect, maxW, maxH)
+		}
+		return Placement{Width: w, Height: h, Crop: full}, nil
+
+	default:
+		return Placement{}, fmt.Errorf("%w: unknown fit %v", errInvalidLayout, l.Fit)
+	}
+}
+
+// wideCells adapts the layout to cells two columns wide, such as emoji, so
+// the output spans no more columns than the layout allows
+func (l Layout) wideCells() Layout {
+	if l.Width > 0 {
+		l.Width = max(1, l.Width/2)
+	}
+	if l.Budget > 0 {
+		l.Budget = max(1, l.Budget/2)
+	}
+	return l
+}
+
+// containIn returns the largest size with the given aspect ratio that fits
+// in maxWidth x maxHeight, never smaller than 1x1
+func containIn(aspect float64, maxWidth, maxHeight int) (int, int) {
+	if aspect > float64(maxWidth)/float64(maxHeight) {
+		return maxWidth, min(maxHeight, atLeastOne(float64(maxWidth)/aspect))
+	}
+	return min(maxWidth, atLeastOne(float64(maxHeight)*aspect)), maxHeight
+}
+
+// budgetFit returns the largest size with the given aspect ratio whose area
+// is at most budget, never smaller than 1x1 unless budget forces a stripe
+func budgetFit(aspect float64, budget int) (int, int) {
+	h := atLeastOne(math.Sqrt(float64(budget) / aspect))
+	w := atLeastOne(float64(h) * aspect)
+	// Extreme aspect ratios floor one side to 1; give the other side what
+	// remains of the budget
+	if w*h > budget {
+		if w == 1 {
+			h = budget
+		} else {
+			w = max(1, budget/h)
+		}
+	}
+	return w, h
+}
+
+// coverCrop returns the centred region of the source with the aspect ratio
+// of the target grid
+func coverCrop(srcWidth, srcHeight, width, height int) image.Rectangle {
+	target := float64(width) / float64(height)
+	if float64(srcWidth)/float64(srcHeight) > target {
+		cropW := min(srcWidth, atLeastOne(math.Round(float64(srcHeight)*target)))
+		x0 := (srcWidth - cropW) / 2
+		return image.Rect(x0, 0, x0+cropW, srcHeight)
+	}
+	cropH := min(srcHeight, atLeastOne(math.Round(float64(srcWidth)/target)))
+	y0 := (srcHeight - cropH) / 2
+	return image.Rect(0, y0, srcWidth, y0+cropH)
+}
+
+func atLeastOne(v float64) int {
+	if v < 1 {
+		return 1
+	}
+	return int(v)
+}
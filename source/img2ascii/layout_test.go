@@ -0,0 +1,193 @@
+package img2ascii
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestLayoutPlace(t *testing.T) {
+	tests := []struct {
+		name          string
+		layout        Layout
+		srcW, srcH    int
+		width, height int
+		crop          image.Rectangle // zero means the full source
+	}{
+		// Legacy scale mode: 65x54 contain
+		{"Contain landscape", Layout{Fit: FitContain, Width: 65, Height: 54}, 640, 480, 65, 48, image.Rectangle{}},
+		{"Contain portrait", Layout{Fit: FitContain, Width: 65, Height: 54}, 480, 640, 40, 54, image.Rectangle{}},
+		{"Contain square", Layout{Fit: FitContain, Width: 65, Height: 54}, 100, 100, 54, 54, image.Rectangle{}},
+		{"Contain upscales", Layout{Fit: FitContain, Width: 65, Height: 54}, 10, 5, 65, 32, image.Rectangle{}},
+		{"Contain 1x1", Layout{Fit: FitContain, Width: 65, Height: 54}, 1, 1, 54, 54, image.Rectangle{}},
+		{"Contain very wide", Layout{Fit: FitContain, Width: 65, Height: 54}, 100000, 1, 65, 1, image.Rectangle{}},
+		{"Contain very tall", Layout{Fit: FitContain, Width: 65, Height: 54}, 1, 100000, 1, 54, image.Rectangle{}},
+
+		// Legacy pixel mode: 1:1 up to 300x200
+		{"No upscale small", Layout{Fit: FitContain, Width: 300, Height: 200, NoUpscale: true}, 40, 30, 40, 30, image.Rectangle{}},
+		{"No upscale 1x1", Layout{Fit: FitContain, Width: 300, Height: 200, NoUpscale: true}, 1, 1, 1, 1, image.Rectangle{}},
+		{"No upscale large", Layout{Fit: FitContain, Width: 300, Height: 200, NoUpscale: true}, 1200, 400, 300, 100, image.Rectangle{}},
+
+		{"Stretch", Layout{Fit: FitStretch, Width: 80, Height: 40}, 1, 100000, 80, 40, image.Rectangle{}},
+
+		{"Cover landscape", Layout{Fit: FitCover, Width: 40, Height: 40}, 200, 100, 40, 40, image.Rect(50, 0, 150, 100)},
+		{"Cover portrait", Layout{Fit: FitCover, Width: 80, Height: 40}, 100, 100, 80, 40, image.Rect(0, 25, 100, 75)},
+		{"Cover very wide", Layout{Fit: FitCover, Width: 10, Height: 10}, 100000, 1, 10, 10, image.Rect(49999, 0, 50000, 1)},
+		{"Cover 1x1", Layout{Fit: FitCover, Width: 80, Height: 40}, 1, 1, 80, 40, image.Rect(0, 0, 1, 1)},
+
+		{"Width", Layout{Fit: FitWidth, Width: 80}, 400, 100, 80, 20, image.Rectangle{}},
+		{"Width very wide", Layout{Fit: FitWidth, Width: 80}, 100000, 1, 80, 1, image.Rectangle{}},
+		{"Width capped", Layout{Fit: FitWidth, Width: 80, Height: 50}, 1, 100000, 1, 50, image.Rectangle{}},
+
+		{"Height", Layout{Fit: FitHeight, Height: 20}, 400, 100, 80, 20, image.Rectangle{}},
+		{"Height very tall", Layout{Fit: FitHeight, Height: 20}, 1, 100000, 1, 20, image.Rectangle{}},
+		{"Height capped", Layout{Fit: FitHeight, Width: 100, Height: 20}, 100000, 1, 100, 1, image.Rectangle{}},
+
+		{"Budget square", Layout{Fit: FitBudget, Budget: 400}, 1000, 1000, 20, 20, image.Rectangle{}},
+		{"Budget landscape", Layout{Fit: FitBudget, Budget: 800}, 200, 100, 40, 20, image.Rectangle{}},
+		{"Budget very wide", Layout{Fit: FitBudget, Budget: 500}, 100000, 1, 500, 1, image.Rectangle{}},
+		{"Budget very tall", Layout{Fit: FitBudget, Budget: 500}, 1, 100000, 1, 500, image.Rectangle{}},
+		{"Budget 1x1", Layout{Fit: FitBudget, Budget: 500}, 1, 1, 22, 22, image.Rectangle{}},
+		{"Budget no upscale", Layout{Fit: FitBudget, Budget: 500, NoUpscale: true}, 1, 1, 1, 1, image.Rectangle{}},
+		{"Budget capped", Layout{Fit: FitBudget, Budget: 10000, Width: 50}, 400, 100, 50, 12, image.Rectangle{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.layout.Place(tt.srcW, tt.srcH)
+			if err != nil {
+				t.Fatalf("Place(%d, %d) error = %v", tt.srcW, tt.srcH, err)
+			}
+			if p.Width != tt.width || p.Height != tt.height {
+				t.Errorf("Place(%d, %d) = %dx%d, want %dx%d", tt.srcW, tt.srcH, p.Width, p.Height, tt.width, tt.height)
+			}
+			crop := tt.crop
+			if crop.Empty() {
+				crop = image.Rect(0, 0, tt.srcW, tt.srcH)
+			}
+			if p.Crop != crop {
+				t.Errorf("Crop = %v, want %v", p.Crop, crop)
+			}
+			if p.Width < 1 || p.Height < 1 {
+				t.Errorf("Zero-sized placement %dx%d", p.Width, p.Height)
+			}
+			if tt.layout.Fit == FitBudget && p.Width*p.Height > tt.layout.Budget {
+				t.Errorf("Placement %dx%d exceeds budget %d", p.Width, p.Height, tt.layout.Budget)
+			}
+		})
+	}
+}
+
+func TestLayoutPlaceErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		layout     Layout
+		srcW, srcH int
+	}{
+		{"Zero source", Layout{Fit: FitContain, Width: 10, Height: 10}, 0, 10},
+		{"Contain without height", Layout{Fit: FitContain, Width: 10}, 10, 10},
+		{"Cover without width", Layout{Fit: FitCover, Height: 10}, 10, 10},
+		{"Stretch without bounds", Layout{Fit: FitStretch}, 10, 10},
+		{"Width without width", Layout{Fit: FitWidth, Height: 10}, 10, 10},
+		{"Height without height", Layout{Fit: FitHeight, Width: 10}, 10, 10},
+		{"Budget without budget", Layout{Fit: FitBudget, Width: 10}, 10, 10},
+		{"Negative bound", Layout{Fit: FitStretch, Width: -1, Height: 10}, 10, 10},
+		{"Unknown fit", Layout{Fit: Fit(99), Width: 10, Height: 10}, 10, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.layout.Place(tt.srcW, tt.srcH); err == nil {
+				t.Error("Expected error but got none")
+			}
+		})
+	}
+}
+
+func TestConversionOptionsLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  ConversionOptions
+		expected Layout
+	}{
+		{"Scale", ConversionOptions{}, Layout{Fit: FitContain, Width: 65, Height: 54}},
+		{"Pixel", ConversionOptions{AspectMode: AspectPixel}, Layout{Fit: FitContain, Width: 300, Height: 200, NoUpscale: true}},
+		{"Fixed", ConversionOptions{AspectMode: AspectFixed, FixedWidth: 80, FixedHeight: 40}, Layout{Fit: FitStretch, Width: 80, Height: 40}},
+		{"Fixed without width", ConversionOptions{AspectMode: AspectFixed, FixedHeight: 40}, Layout{Fit: FitStretch, Width: 120, Height: 90}},
+		{"Fixed without size", ConversionOptions{AspectMode: AspectFixed}, Layout{Fit: FitStretch, Width: 120, Height: 90}},
+		{"Explicit", ConversionOptions{AspectMode: AspectPixel, Layout: Layout{Fit: FitWidth, Width: 10}}, Layout{Fit: FitWidth, Width: 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.options.layout(120, 90); got != tt.expected {
+				t.Errorf("layout() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvertCover(t *testing.T) {
+	// 30x10: black, white, black thirds; cover into a square keeps the middle
+	src := createTestImage(30, 10, color.Black)
+	for y := 0; y < 10; y++ {
+		for x := 10; x < 20; x++ {
+			src.Set(x, y, color.White)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	imgPath := writeTestFile(t, buf.Bytes())
+
+	art, err := Convert(imgPath, ConversionOptions{Layout: Layout{Fit: FitCover, Width: 4, Height: 4}})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	for _, row := range art.Rows {
+		if row != "...." {
+			t.Fatalf("Rows = %q, want only the white middle third", art.Rows)
+		}
+	}
+	if art.Source != (Resolution{Width: 30, Height: 10}) {
+		t.Errorf("Source = %+v, want 30x10", art.Source)
+	}
+}
+
+func TestConvertFixedWithoutSize(t *testing.T) {
+	// A zero fixed dimension keeps the source size, as before layouts
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, createTestImage(6, 3, color.Black)); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	imgPath := writeTestFile(t, buf.Bytes())
+
+	for _, options := range []ConversionOptions{
+		{AspectMode: AspectFixed},
+		{AspectMode: AspectFixed, FixedWidth: 40},
+		{AspectMode: AspectFixed, FixedHeight: 20},
+	} {
+		art, err := Convert(imgPath, options)
+		if err != nil {
+			t.Fatalf("Convert(%+v) error = %v", options, err)
+		}
+		if art.Width != 6 || art.Height != 3 {
+			t.Errorf("Convert(%+v) = %dx%d, want the source size 6x3", options, art.Width, art.Height)
+		}
+	}
+}
+
+func TestParseFit(t *testing.T) {
+	for f := FitContain; f <= FitBudget; f++ {
+		got, err := ParseFit(f.String())
+		if err != nil || got != f {
+			t.Errorf("ParseFit(%q) = %v, %v", f.String(), got, err)
+		}
+	}
+	if _, err := ParseFit("zoom"); err == nil {
+		t.Error("Expected error for unknown fit")
+	}
+}
@@ -0,0 +1,98 @@
+package img2ascii
+
+import (
+	"fmt"
+	"math"
+)
+
+// LuminanceModel selects how a pixel's colour is reduced to a single
+// brightness value before it is mapped to a character
+type LuminanceModel int
+
+const (
+	LumRec709       LuminanceModel = iota // Rec.709 weights on gamma-encoded sRGB (default)
+	LumRec709Linear                       // Rec.709 weights on linear light, re-encoded to sRGB
+	LumRec601                             // Rec.601 (SD video) weights on gamma-encoded sRGB
+	LumLab                                // CIELAB L*, perceptually uniform lightness
+	LumAverage                            // Unweighted mean of R, G and B
+)
+
+func (m LuminanceModel) String() string {
+	switch m {
+	case LumRec709:
+		return "rec709"
+	case LumRec709Linear:
+		return "rec709-linear"
+	case LumRec601:
+		return "rec601"
+	case LumLab:
+		return "lab"
+	case LumAverage:
+		return "average"
+	default:
+		return fmt.Sprintf("LuminanceModel(%d)", int(m))
+	}
+}
+
+// ParseLuminanceModel returns the model named by String
+func ParseLuminanceModel(name string) (LuminanceModel, error) {
+	for m := LumRec709; m <= LumAverage; m++ {
+		if m.String() == name {
+			return m, nil
+		}
+	}
+	return LumRec709, fmt.Errorf("unknown luminance model: %q", name)
+}
+
+// srgbToLinear decodes an sRGB component in [0, 1] to linear light
+func srgbToLinear(c float64) float64 {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB encodes linear light in [0, 1] with the sRGB transfer curve
+func linearToSRGB(l float64) float64 {
+	if l <= 0.0031308 {
+		return 12.92 * l
+	}
+	return 1.055*math.Pow(l, 1/2.4) - 0.055
+}
+
+// labLightness returns CIELAB L* scaled to [0, 1] for relative luminance y
+func labLightness(y float64) float64 {
+	const epsilon = 216.0 / 24389.0
+	const kappa = 24389.0 / 27.0
+	if y <= epsilon {
+		return kappa * y / 100
+	}
+	return (116*math.Cbrt(y) - 16) / 100
+}
+
+// luminance16 reduces 16-bit sRGB components to a brightness in [0, 1]
+func luminance16(model LuminanceModel, r, g, b uint16) float64 {
+	const max = 0xffff
+	rf, gf, bf := float64(r)/max, float64(g)/max, float64(b)/max
+	switch model {
+	case LumRec709Linear:
+		y := 0.2126*srgbToLinear(rf) + 0.7152*srgbToLinear(gf) + 0.0722*srgbToLinear(bf)
+		return linearToSRGB(y)
+	case LumRec601:
+		return 0.299*rf + 0.587*gf + 0.114*bf
+	case LumLab:
+		y := 0.2126*srgbToLinear(rf) + 0.7152*srgbToLinear(gf) + 0.0722*srgbToLinear(bf)
+		return labLightness(y)
+	case LumAverage:
+		return (rf + gf + bf) / 3
+	default:
+		return 0.2126*rf + 0.7152*gf + 0.0722*bf
+	}
+}
+
+// luminanceScore returns the brightness of a 16-bit pixel on the 0-255 scale
+// used by the character ramps
+func luminanceScore(model LuminanceModel, r, g, b uint16) int {
+	l := math.Round(luminance16(model, r, g, b) * 255)
+	return int(math.Max(0, math.Min(255, l)))
+}
@@ -0,0 +1,85 @@
+package img2ascii
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestLuminanceScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		r, g, b uint8
+		// Expected scores for rec709, rec709-linear, rec601, lab, average
+		expected [5]int
+	}{
+		{"Black", 0, 0, 0, [5]int{0, 0, 0, 0, 0}},
+		{"White", 255, 255, 255, [5]int{255, 255, 255, 255, 255}},
+		{"Red", 255, 0, 0, [5]int{54, 127, 76, 136, 85}},
+		{"Green", 0, 255, 0, [5]int{182, 220, 150, 224, 85}},
+		{"Blue", 0, 0, 255, [5]int{18, 76, 29, 82, 85}},
+		{"Gray", 128, 128, 128, [5]int{128, 128, 128, 137, 128}},
+		{"Orange", 255, 128, 0, [5]int{146, 163, 151, 171, 128}},
+	}
+	models := []LuminanceModel{LumRec709, LumRec709Linear, LumRec601, LumLab, LumAverage}
+
+	for _, tt := range tests {
+		for i, model := range models {
+			t.Run(tt.name+"/"+model.String(), func(t *testing.T) {
+				r, g, b := uint16(tt.r)*0x101, uint16(tt.g)*0x101, uint16(tt.b)*0x101
+				result := luminanceScore(model, r, g, b)
+				if result != tt.expected[i] {
+					t.Errorf("luminanceScore(%v, %d, %d, %d) = %d, want %d",
+						model, tt.r, tt.g, tt.b, result, tt.expected[i])
+				}
+			})
+		}
+	}
+}
+
+func TestSRGBRoundTrip(t *testing.T) {
+	for v := 0; v <= 255; v++ {
+		c := float64(v) / 255
+		if got := linearToSRGB(srgbToLinear(c)); got-c > 1e-9 || c-got > 1e-9 {
+			t.Fatalf("linearToSRGB(srgbToLinear(%v)) = %v", c, got)
+		}
+	}
+}
+
+func TestParseLuminanceModel(t *testing.T) {
+	for m := LumRec709; m <= LumAverage; m++ {
+		got, err := ParseLuminanceModel(m.String())
+		if err != nil || got != m {
+			t.Errorf("ParseLuminanceModel(%q) = %v, %v", m.String(), got, err)
+		}
+	}
+	if _, err := ParseLuminanceModel("hsl"); err == nil {
+		t.Error("Expected error for unknown model")
+	}
+}
+
+func TestNewImagePreserves16Bit(t *testing.T) {
+	src := image.NewRGBA64(image.Rect(0, 0, 2, 1))
+	src.SetRGBA64(0, 0, color.RGBA64{R: 0x1234, G: 0x5678, B: 0x9abc, A: 0xffff})
+	src.SetRGBA64(1, 0, color.RGBA64{R: 0x12ff, G: 0x56ff, B: 0x9aff, A: 0xffff})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	imgPath := writeTestFile(t, buf.Bytes())
+
+	img, err := newImage(imgPath, Placement{}, DefaultLimits, nil)
+	if err != nil {
+		t.Fatalf("newImage() error = %v", err)
+	}
+	r0, g0, b0, _, _ := img.pixel(0)
+	r1, _, _, _, _ := img.pixel(1)
+	if r0 != 0x1234 || g0 != 0x5678 || b0 != 0x9abc {
+		t.Errorf("pixel(0) = %04x %04x %04x, want 1234 5678 9abc", r0, g0, b0)
+	}
+	if r0>>8 != r1>>8 || r0 == r1 {
+		t.Errorf("Low byte lost: pixel(0).R = %04x, pixel(1).R = %04x", r0, r1)
+	}
+}
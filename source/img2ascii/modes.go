@@ -0,0 +1,155 @@
+package img2ascii
+
+import "strings"
+
+const (
+	defaultPosterizeLevels = 4
+	defaultFillText        = "img2ascii"
+
+	// textFillRamp marks ink and blank cells for ModeTextFill, darkest first.
+	// Ink cells are drawn from the fill text instead of the marker.
+	textFillRamp = "# "
+)
+
+// cellMapper turns a cell's luminance into a character for one conversion.
+// ModeTextFill consumes its text as cells are mapped, so cells must be
+// mapped once each in reading order.
+type cellMapper struct {
+	mode      ConversionMode
+	ramp      []rune // characters the mapper can emit, darkest first; the fill text for ModeTextFill
+	threshold int    // ModeThreshold and ModeTextFill
+
+	inkDark bool // ModeTextFill: dark cells are drawn rather than bright ones
+	fillAll bool // ModeTextFill: every cell is drawn
+	next    int  // ModeTextFill: index of the next fill character
+}
+
+func newCellMapper(options ConversionOptions, ramp []rune, lScores []int) cellMapper {
+	switch options.Mode {
+	case ModeThreshold:
+		return cellMapper{
+			mode:      ModeThreshold,
+			ramp:      []rune{ramp[0], ramp[len(ramp)-1]},
+			threshold: resolveThreshold(options.Threshold, lScores),
+		}
+	case ModeTextFill:
+		return cellMapper{
+			mode:      ModeTextFill,
+			ramp:      fillRunes(options.FillText),
+			threshold: resolveThreshold(options.Threshold, lScores),
+			inkDark:   ramp[0] != ' ',
+			fillAll:   options.FillAll,
+		}
+	case ModePosterize:
+		levels := options.Levels
+		if levels <= 0 {
+			levels = defaultPosterizeLevels
+		}
+		if levels < 2 {
+			levels = 2
+		}
+		if levels > len(ramp) {
+			levels = len(ramp)
+		}
+		// Spread the levels evenly across the ramp, always keeping both ends
+		picked := make([]rune, levels)
+		for l := range picked {
+			picked[l] = ramp[0]
+			if levels > 1 {
+				picked[l] = ramp[l*(len(ramp)-1)/(levels-1)]
+			}
+		}
+		return cellMapper{mode: ModePosterize, ramp: picked}
+	default:
+		return cellMapper{mode: options.Mode, ramp: ramp}
+	}
+}
+
+func (m *cellMapper) char(luminance int) rune {
+	switch m.mode {
+	case ModeThreshold:
+		if luminance < m.threshold {
+			return m.ramp[0]
+		}
+		return m.ramp[1]
+	case ModeTextFill:
+		if !m.fillAll && (luminance < m.threshold) != m.inkDark {
+			return ' '
+		}
+		r := m.ramp[m.next]
+		m.next = (m.next + 1) % len(m.ramp)
+		return r
+	case ModePosterize:
+		level := luminance * len(m.ramp) / 256
+		if level >= len(m.ramp) {
+			level = len(m.ramp) - 1
+		}
+		return m.ramp[level]
+	default:
+		return rampChar(m.ramp, luminance)
+	}
+}
+
+// resolveThreshold clamps a requested cut-off to 1-255, choosing one with
+// Otsu's method when none is given
+func resolveThreshold(requested int, lScores []int) int {
+	if requested <= 0 {
+		return otsuThreshold(lScores)
+	}
+	return min(requested, 255)
+}
+
+// fillRunes returns the sequence ModeTextFill repeats: the text with runs of
+// whitespace collapsed to one space, followed by a space separating each
+// repetition
+func fillRunes(text string) []rune {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		words = []string{defaultFillText}
+	}
+	return []rune(strings.Join(words, " ") + " ")
+}
+
+// otsuThreshold picks the cut-off that maximizes the between-class variance
+// of the luminance histogram (Otsu, 1979). Cells below it are dark. An image
+// with a single tone has no meaningful split, so the midpoint is returned.
+func otsuThreshold(lScores []int) int {
+	var hist [256]int
+	for _, l := range lScores {
+		if l < 0 {
+			l = 0
+		} else if l > 255 {
+			l = 255
+		}
+		hist[l]++
+	}
+
+	total := len(lScores)
+	var sum float64
+	for l, n := range hist {
+		sum += float64(l * n)
+	}
+
+	var sumDark float64
+	var countDark int
+	best, bestVariance := 128, 0.0
+	for t := 0; t < 255; t++ {
+		countDark += hist[t]
+		if countDark == 0 {
+			continue
+		}
+		countLight := total - countDark
+		if countLight == 0 {
+			break
+		}
+		sumDark += float64(t * hist[t])
+		meanDark := sumDark / float64(countDark)
+		meanLight := (sum - sumDark) / float64(countLight)
+		variance := float64(countDark) * float64(countLight) * (meanDark - meanLight) * (meanDark - meanLight)
+		if variance > bestVariance {
+			bestVariance = variance
+			best = t + 1
+		}
+	}
+	return best
+}
@@ -0,0 +1,140 @@
+package img2ascii
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestOtsuThreshold(t *testing.T) {
+	tests := []struct {
+		name     string
+		scores   []int
+		min, max int
+	}{
+		{"Bimodal", []int{10, 12, 11, 10, 200, 210, 205, 199}, 13, 199},
+		{"Skewed bimodal", []int{50, 50, 50, 50, 50, 50, 50, 180}, 51, 180},
+		{"Uniform", []int{90, 90, 90}, 128, 128},
+		{"Empty", nil, 128, 128},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := otsuThreshold(tt.scores)
+			if result < tt.min || result > tt.max {
+				t.Errorf("otsuThreshold(%v) = %d, want in [%d, %d]", tt.scores, result, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestCellMapper(t *testing.T) {
+	ramp := []rune(defaultASCIIChars)
+	tests := []struct {
+		name      string
+		options   ConversionOptions
+		luminance []int
+		expected  string
+		usedRamp  string
+	}{
+		{"Default", ConversionOptions{}, []int{0, 128, 255}, "@).", defaultASCIIChars},
+		{"Fixed threshold", ConversionOptions{Mode: ModeThreshold, Threshold: 100}, []int{0, 99, 100, 255}, "@@..", "@."},
+		{"Posterize default levels", ConversionOptions{Mode: ModePosterize}, []int{0, 63, 64, 128, 192, 255}, "@@ol..", "@ol."},
+		{"Posterize two levels", ConversionOptions{Mode: ModePosterize, Levels: 2}, []int{0, 127, 128, 255}, "@@..", "@."},
+		{"Posterize capped by ramp", ConversionOptions{Mode: ModePosterize, Levels: 40}, []int{0, 255}, "@.", defaultASCIIChars},
+		{"Text fill", ConversionOptions{Mode: ModeTextFill, FillText: "ab", Threshold: 100}, []int{0, 200, 0, 0, 0}, "a b a", "ab "},
+		{"Text fill collapses whitespace", ConversionOptions{Mode: ModeTextFill, FillText: "  a\t\tb ", Threshold: 100}, []int{0, 0, 0, 0}, "a b ", "a b "},
+		{"Text fill all cells", ConversionOptions{Mode: ModeTextFill, FillText: "xy", FillAll: true}, []int{0, 255, 255}, "xy ", "xy "},
+		{"Text fill default text", ConversionOptions{Mode: ModeTextFill, Threshold: 100}, []int{0, 0, 0}, "img", "img2ascii "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newCellMapper(tt.options, ramp, tt.luminance)
+			var got []rune
+			for _, l := range tt.luminance {
+				got = append(got, m.char(l))
+			}
+			if string(got) != tt.expected {
+				t.Errorf("chars = %q, want %q", string(got), tt.expected)
+			}
+			if string(m.ramp) != tt.usedRamp {
+				t.Errorf("ramp = %q, want %q", string(m.ramp), tt.usedRamp)
+			}
+		})
+	}
+}
+
+func TestImage_toArtThreshold(t *testing.T) {
+	// Left half dark, right half light
+	width, height := 4, 2
+	testImg := createTestImage(width, height, color.RGBA{R: 20, G: 20, B: 20, A: 255})
+	for y := 0; y < height; y++ {
+		for x := width / 2; x < width; x++ {
+			testImg.Set(x, y, color.RGBA{R: 230, G: 230, B: 230, A: 255})
+		}
+	}
+	img := &Image{Res: Resolution{Width: width, Height: height}, Data: testImg.Pix}
+
+	art := img.toArt(ConversionOptions{Mode: ModeThreshold, Characters: "█░"})
+	if art.Rows[0] != "██░░" || art.Rows[1] != "██░░" {
+		t.Errorf("Rows = %q, want dark left half and light right half", art.Rows)
+	}
+	if art.Threshold <= 20 || art.Threshold > 230 {
+		t.Errorf("Automatic threshold = %d, want between the two tones", art.Threshold)
+	}
+	if art.Ramp != "█░" {
+		t.Errorf("Ramp = %q, want %q", art.Ramp, "█░")
+	}
+
+	art = img.toArt(ConversionOptions{Mode: ModeThreshold, Characters: "█░", Reverse: true})
+	if art.Rows[0] != "░░██" {
+		t.Errorf("Reversed row = %q, want %q", art.Rows[0], "░░██")
+	}
+}
+
+func TestImage_toArtTextFill(t *testing.T) {
+	// A bright 2x2 square in the middle of a dark 4x3 image
+	width, height := 4, 3
+	testImg := createTestImage(width, height, color.Black)
+	for y := 1; y < 3; y++ {
+		for x := 1; x < 3; x++ {
+			testImg.Set(x, y, color.White)
+		}
+	}
+	img := &Image{Res: Resolution{Width: width, Height: height}, Data: testImg.Pix}
+
+	// On a dark background the bright square is drawn, continuing across rows
+	art := img.toArt(ConversionOptions{Mode: ModeTextFill, FillText: "Go", TargetBackground: BackgroundDark})
+	want := []string{"    ", " Go ", "  G "}
+	for y, row := range want {
+		if art.Rows[y] != row {
+			t.Errorf("Dark rows = %q, want %q", art.Rows, want)
+			break
+		}
+	}
+	if art.Ramp != "Go " {
+		t.Errorf("Ramp = %q, want %q", art.Ramp, "Go ")
+	}
+
+	// On a light background the dark surround is drawn instead
+	art = img.toArt(ConversionOptions{Mode: ModeTextFill, FillText: "Go", TargetBackground: BackgroundLight})
+	want = []string{"Go G", "o   ", "G  o"}
+	for y, row := range want {
+		if art.Rows[y] != row {
+			t.Errorf("Light rows = %q, want %q", art.Rows, want)
+			break
+		}
+	}
+}
+
+func TestParseConversionMode(t *testing.T) {
+	for m := ModeDefault; m <= ModeEmoji; m++ {
+		got, err := ParseConversionMode(m.String())
+		if err != nil || got != m {
+			t.Errorf("ParseConversionMode(%q) = %v, %v", m.String(), got, err)
+		}
+	}
+	if _, err := ParseConversionMode("sepia"); err == nil {
+		t.Error("Expected error for unknown mode")
+	}
+}
@@ -0,0 +1,273 @@
+package img2ascii
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"sort"
+)
+
+const maxSixelColors = 256
+
+var errNoRaster = errors.New("art has no image data")
+
+// Sixel renders the resampled image behind an Art as a DEC Sixel graphics
+// stream, for a preview in terminals such as xterm, foot and mlterm. The
+// image is quantized to at most Colors colours with median cut. Each cell is
+// drawn as a Scale x Scale block of pixels so the preview is readable at the
+// size of the grid. Transparent pixels are left as the terminal background.
+type Sixel struct {
+	Colors int // palette size, 2-256; 0 means 256
+	Scale  int // pixels per cell; 0 means 1
+}
+
+func (Sixel) ContentType() string { return "image/x-sixel" }
+
+func (s Sixel) Render(w io.Writer, art *Art) error {
+	if art.raster == nil {
+		return errNoRaster
+	}
+	colors := s.Colors
+	if colors <= 0 || colors > maxSixelColors {
+		colors = maxSixelColors
+	}
+	colors = max(colors, 2)
+	scale := max(s.Scale, 1)
+
+	palette, indices := quantize(art.raster, colors)
+	bounds := art.raster.Bounds()
+	return writeSixel(w, palette, indices, bounds.Dx(), bounds.Dy(), scale)
+}
+
+// writeSixel encodes a width x height grid of palette indices, -1 meaning
+// transparent, with every pixel repeated scale times in each direction
+func writeSixel(w io.Writer, palette []color.RGBA, indices []int, width, height, scale int) error {
+	bw := bufio.NewWriter(w)
+	outW, outH := width*scale, height*scale
+
+	// DCS with P2=1 so unset pixels keep the background, then raster
+	// attributes (1:1 pixel aspect ratio and the image size)
+	fmt.Fprintf(bw, "\x1bP0;1;0q\"1;1;%d;%d", outW, outH)
+	for n, c := range palette {
+		fmt.Fprintf(bw, "#%d;2;%d;%d;%d", n, percent(c.R), percent(c.G), percent(c.B))
+	}
+
+	at := func(x, y int) int { return indices[(y/scale)*width+x/scale] }
+	bits := make([][]byte, len(palette))
+	for top := 0; top < outH; top += 6 {
+		// Collect one six-pixel band per colour
+		used := make([]bool, len(palette))
+		for n := range bits {
+			bits[n] = bits[n][:0]
+		}
+		for x := 0; x < outW; x++ {
+			for n := range bits {
+				bits[n] = append(bits[n], 0)
+			}
+			for dy := 0; dy < 6 && top+dy < outH; dy++ {
+				if n := at(x, top+dy); n >= 0 {
+					bits[n][x] |= 1 << dy
+					used[n] = true
+				}
+			}
+		}
+
+		first := true
+		for n, band := range bits {
+			if !used[n] {
+				continue
+			}
+			if !first {
+				bw.WriteByte('$') // carriage return to overprint the band
+			}
+			first = false
+			fmt.Fprintf(bw, "#%d", n)
+			writeSixelRuns(bw, band)
+		}
+		if top+6 < outH {
+			bw.WriteByte('-')
+		}
+	}
+	bw.WriteString("\x1b\\")
+	return bw.Flush()
+}
+
+// writeSixelRuns writes one colour's band with run-length encoding, dropping
+// trailing empty columns
+func writeSixelRuns(bw *bufio.Writer, band []byte) {
+	end := len(band)
+	for end > 0 && band[end-1] == 0 {
+		end--
+	}
+	for x := 0; x < end; {
+		run := 1
+		for x+run < end && band[x+run] == band[x] {
+			run++
+		}
+		ch := byte('?' + band[x])
+		if run > 3 {
+			fmt.Fprintf(bw, "!%d%c", run, ch)
+		} else {
+			for i := 0; i < run; i++ {
+				bw.WriteByte(ch)
+			}
+		}
+		x += run
+	}
+}
+
+func percent(v uint8) int {
+	return (int(v)*100 + 127) / 255
+}
+
+// quantize reduces img to at most maxColors colours with median cut. It
+// returns the palette and each pixel's palette index in row order, with
+// -1 for mostly transparent pixels.
+func quantize(img *image.RGBA64, maxColors int) ([]color.RGBA, []int) {
+	bounds := img.Bounds()
+	indices := make([]int, bounds.Dx()*bounds.Dy())
+	counts := make(map[color.RGBA]int)
+	pixels := make([]color.RGBA, len(indices))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			i := (y-bounds.Min.Y)*bounds.Dx() + x - bounds.Min.X
+			c := img.RGBA64At(x, y)
+			if c.A < 0x8000 {
+				indices[i] = -1
+				continue
+			}
+			// Undo the alpha premultiplication, clamping channels that
+			// exceed alpha in malformed input
+			p := color.RGBA{
+				R: uint8(uint32(min(c.R, c.A)) * 0xff / uint32(c.A)),
+				G: uint8(uint32(min(c.G, c.A)) * 0xff / uint32(c.A)),
+				B: uint8(uint32(min(c.B, c.A)) * 0xff / uint32(c.A)),
+				A: 0xff,
+			}
+			pixels[i] = p
+			counts[p]++
+		}
+	}
+
+	entries := make([]colorCount, 0, len(counts))
+	for c, n := range counts {
+		entries = append(entries, colorCount{c, n})
+	}
+	palette := medianCut(entries, maxColors)
+
+	lookup := make(map[color.RGBA]int, len(counts))
+	for i, idx := range indices {
+		if idx < 0 {
+			continue
+		}
+		n, ok := lookup[pixels[i]]
+		if !ok {
+			n = nearestColor(palette, pixels[i])
+			lookup[pixels[i]] = n
+		}
+		indices[i] = n
+	}
+	return palette, indices
+}
+
+type colorCount struct {
+	c color.RGBA
+	n int
+}
+
+// medianCut splits the colour set into at most maxColors boxes, each time
+// halving the box with the widest channel range at its weighted median, and
+// returns the weighted mean colour of each box
+func medianCut(entries []colorCount, maxColors int) []color.RGBA {
+	if len(entries) == 0 {
+		return nil
+	}
+	// Map iteration order is random; sort so the palette is deterministic
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i].c, entries[j].c
+		return uint32(a.R)<<16|uint32(a.G)<<8|uint32(a.B) < uint32(b.R)<<16|uint32(b.G)<<8|uint32(b.B)
+	})
+	boxes := [][]colorCount{entries}
+	for len(boxes) < maxColors {
+		best, bestRange, bestChannel := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			for ch := 0; ch < 3; ch++ {
+				lo, hi := channelRange(box, ch)
+				if hi-lo > bestRange {
+					best, bestRange, bestChannel = i, hi-lo, ch
+				}
+			}
+		}
+		if best < 0 {
+			break
+		}
+		box := boxes[best]
+		sort.SliceStable(box, func(i, j int) bool {
+			return channel(box[i].c, bestChannel) < channel(box[j].c, bestChannel)
+		})
+		total := 0
+		for _, e := range box {
+			total += e.n
+		}
+		split, acc := 1, 0
+		for i, e := range box[:len(box)-1] {
+			acc += e.n
+			split = i + 1
+			if acc*2 >= total {
+				break
+			}
+		}
+		boxes[best] = box[:split]
+		boxes = append(boxes, box[split:])
+	}
+
+	palette := make([]color.RGBA, len(boxes))
+	for i, box := range boxes {
+		var r, g, b, n int
+		for _, e := range box {
+			r += int(e.c.R) * e.n
+			g += int(e.c.G) * e.n
+			b += int(e.c.B) * e.n
+			n += e.n
+		}
+		palette[i] = color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: 0xff}
+	}
+	return palette
+}
+
+func channel(c color.RGBA, ch int) int {
+	switch ch {
+	case 0:
+		return int(c.R)
+	case 1:
+		return int(c.G)
+	default:
+		return int(c.B)
+	}
+}
+
+func channelRange(box []colorCount, ch int) (lo, hi int) {
+	lo, hi = 255, 0
+	for _, e := range box {
+		v := channel(e.c, ch)
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	return lo, hi
+}
+
+func nearestColor(palette []color.RGBA, c color.RGBA) int {
+	best, bestDist := 0, -1
+	for i, p := range palette {
+		dr, dg, db := int(p.R)-int(c.R), int(p.G)-int(c.G), int(p.B)-int(c.B)
+		if d := dr*dr + dg*dg + db*db; bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
@@ -0,0 +1,100 @@
+package img2ascii
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestSixelRender(t *testing.T) {
+	testImg := createTestImage(2, 1, color.RGBA{R: 255, A: 255})
+	testImg.Set(1, 0, color.RGBA{B: 255, A: 255})
+	img := &Image{Res: Resolution{Width: 2, Height: 1}, Data: testImg.Pix}
+	art := img.toArt(ConversionOptions{})
+
+	var buf bytes.Buffer
+	if err := (Sixel{}).Render(&buf, art); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := "\x1bP0;1;0q\"1;1;2;1" +
+		"#0;2;0;0;100#1;2;100;0;0" + // palette: blue, red
+		"#0?@$#1@" + // blue in column 2, red in column 1
+		"\x1b\\"
+	if buf.String() != want {
+		t.Errorf("Render() = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestSixelScaleAndBands(t *testing.T) {
+	img := &Image{Res: Resolution{Width: 3, Height: 2}, Data: createTestImage(3, 2, color.White).Pix}
+	art := img.toArt(ConversionOptions{})
+
+	var buf bytes.Buffer
+	if err := (Sixel{Scale: 4}).Render(&buf, art); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "\"1;1;12;8") {
+		t.Errorf("Raster attributes missing 12x8 size: %q", out)
+	}
+	// 8 rows need two bands: six full rows, then two
+	if !strings.Contains(out, "#0!12~-#0!12B") {
+		t.Errorf("Unexpected bands: %q", out)
+	}
+}
+
+func TestSixelTransparency(t *testing.T) {
+	testImg := createTestImage(2, 1, color.Transparent)
+	testImg.Set(0, 0, color.Black)
+	img := &Image{Res: Resolution{Width: 2, Height: 1}, Data: testImg.Pix}
+
+	var buf bytes.Buffer
+	if err := (Sixel{}).Render(&buf, img.toArt(ConversionOptions{})); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "#0;2;0;0;0#0@\x1b\\") {
+		t.Errorf("Transparent pixel was painted: %q", buf.String())
+	}
+}
+
+func TestSixelRequiresRaster(t *testing.T) {
+	err := (Sixel{}).Render(&bytes.Buffer{}, &Art{Width: 1, Height: 1, Rows: []string{"@"}})
+	if !errors.Is(err, errNoRaster) {
+		t.Errorf("Render() error = %v, want errNoRaster", err)
+	}
+}
+
+func TestQuantize(t *testing.T) {
+	// A gradient with 64 distinct greys reduced to 8 colours
+	img := createTestImage(64, 1, color.Black)
+	for x := 0; x < 64; x++ {
+		v := uint8(x * 4)
+		img.Set(x, 0, color.RGBA{R: v, G: v, B: v, A: 255})
+	}
+	palette, indices := quantize(img, 8)
+	if len(palette) != 8 {
+		t.Fatalf("Palette has %d colours, want 8", len(palette))
+	}
+	for x := 1; x < 64; x++ {
+		if palette[indices[x]].R < palette[indices[x-1]].R {
+			t.Fatalf("Quantized gradient is not monotonic at x=%d", x)
+		}
+	}
+
+	// Fewer colours than the limit are kept exactly
+	palette, _ = quantize(createTestImage(4, 4, color.RGBA{R: 10, G: 20, B: 30, A: 255}), 256)
+	if len(palette) != 1 || palette[0] != (color.RGBA{R: 10, G: 20, B: 30, A: 255}) {
+		t.Errorf("Palette = %v, want the single input colour", palette)
+	}
+
+	// A channel above alpha is clamped rather than wrapping around
+	img = image.NewRGBA64(image.Rect(0, 0, 1, 1))
+	img.SetRGBA64(0, 0, color.RGBA64{R: 0xc000, A: 0x8000})
+	palette, _ = quantize(img, 256)
+	if want := (color.RGBA{R: 255, A: 255}); len(palette) != 1 || palette[0] != want {
+		t.Errorf("Palette = %v, want %v", palette, want)
+	}
+}
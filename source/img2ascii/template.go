@@ -0,0 +1,333 @@
+package img2ascii
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Errors returned when a template exceeds its TemplateLimits
+var (
+	ErrOutputTooLarge  = errors.New("template output too large")
+	ErrTemplateTimeout = errors.New("template timed out")
+)
+
+// TemplateLimits bounds the work a template may do for one Art. Once Timeout
+// has passed, Render returns and the template is stopped at its next write or
+// function call. A loop that does neither runs to its end and its CPU is not
+// reclaimed, so MaxCells bounds the data such loops can range over; templates
+// are trusted not to range over large numbers of their own.
+type TemplateLimits struct {
+	Timeout   time.Duration
+	MaxOutput int // bytes
+	MaxCells  int // width*height of the Art; larger art fails with ErrOutputTooLarge
+}
+
+// DefaultTemplateLimits are the limits used for templates loaded by the server
+var DefaultTemplateLimits = TemplateLimits{
+	Timeout:   2 * time.Second,
+	MaxOutput: 1 << 20,
+	MaxCells:  200 * 100,
+}
+
+// TemplateData is the value a format template is executed with
+type TemplateData struct {
+	Width      int
+	Height     int
+	Rows       []string
+	Cells      [][]TemplateCell // indexed [row][column]
+	Ramp       string
+	Mode       string
+	Luminance  string // the luminance model
+	Background string
+	Threshold  int
+	Source     Resolution
+}
+
+// TemplateCell is one cell of the art
+type TemplateCell struct {
+	X, Y       int
+	Char       string
+	Color      string // "#rrggbb"
+	R, G, B, A uint8
+	Luminance  int // 0-255 under the conversion's luminance model
+}
+
+// templateFuncs returns the functions available to templates. Each fails
+// once stopped is set, ending a timed-out template at its next call.
+func templateFuncs(stopped *atomic.Bool) template.FuncMap {
+	check := func() error {
+		if stopped.Load() {
+			return ErrTemplateTimeout
+		}
+		return nil
+	}
+	return template.FuncMap{
+		"json": func(v any) (string, error) {
+			if err := check(); err != nil {
+				return "", err
+			}
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"quote":   func(s string) (string, error) { return strconv.Quote(s), check() },
+		"join":    func(sep string, elems []string) (string, error) { return strings.Join(elems, sep), check() },
+		"replace": func(old, new, s string) (string, error) { return strings.ReplaceAll(s, old, new), check() },
+		"upper":   func(s string) (string, error) { return strings.ToUpper(s), check() },
+		"lower":   func(s string) (string, error) { return strings.ToLower(s), check() },
+		"add":     func(a, b int) (int, error) { return a + b, check() },
+		"sub":     func(a, b int) (int, error) { return a - b, check() },
+	}
+}
+
+// Template renders an Art with a user-supplied text/template, or an
+// html/template for HTML and XML output. Besides the built-in functions,
+// templates can use json, quote, join, replace, upper, lower, add and sub;
+// join and replace take the string last so they can end a pipeline.
+type Template struct {
+	text        *template.Template
+	html        *htmltemplate.Template // set instead of text for markup
+	contentType string
+	limits      TemplateLimits
+}
+
+// ParseTemplate parses text as a format template. An empty contentType means
+// plain text. HTML and XML content types are parsed with html/template, so
+// the art's characters are escaped for the markup they appear in.
+func ParseTemplate(name, text, contentType string, limits TemplateLimits) (*Template, error) {
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+	t := &Template{contentType: contentType, limits: limits}
+	var err error
+	funcs := templateFuncs(new(atomic.Bool))
+	if isMarkup(contentType) {
+		t.html, err = htmltemplate.New(name).Option("missingkey=error").Funcs(htmltemplate.FuncMap(funcs)).Parse(text)
+	} else {
+		t.text, err = template.New(name).Option("missingkey=error").Funcs(funcs).Parse(text)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// isMarkup reports whether contentType is HTML or XML
+func isMarkup(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "text/html" || mediaType == "text/xml" || mediaType == "application/xml" ||
+		strings.HasSuffix(mediaType, "+xml")
+}
+
+// instance returns a copy of the template whose functions fail once stopped
+// is set
+func (t *Template) instance(stopped *atomic.Bool) (interface {
+	Execute(io.Writer, any) error
+}, error) {
+	funcs := templateFuncs(stopped)
+	if t.html != nil {
+		tmpl, err := t.html.Clone()
+		if err != nil {
+			return nil, err
+		}
+		return tmpl.Funcs(htmltemplate.FuncMap(funcs)), nil
+	}
+	tmpl, err := t.text.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.Funcs(funcs), nil
+}
+
+func (t *Template) ContentType() string { return t.contentType }
+
+// Render executes the template, writing nothing to w unless it completes
+// within the limits
+func (t *Template) Render(w io.Writer, art *Art) error {
+	if t.limits.MaxCells > 0 && art.Width*art.Height > t.limits.MaxCells {
+		return ErrOutputTooLarge
+	}
+	out := &limitedBuffer{max: t.limits.MaxOutput}
+	// Each render gets its own functions so they can see when it is stopped
+	tmpl, err := t.instance(&out.stopped)
+	if err != nil {
+		return err
+	}
+
+	data := newTemplateData(art)
+	done := make(chan error, 1)
+	go func() { done <- tmpl.Execute(out, data) }()
+
+	var timeout <-chan time.Time
+	if t.limits.Timeout > 0 {
+		timer := time.NewTimer(t.limits.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case err := <-done:
+		if errors.Is(err, ErrOutputTooLarge) {
+			return ErrOutputTooLarge
+		}
+		if err != nil {
+			return err
+		}
+	case <-timeout:
+		out.stopped.Store(true)
+		return ErrTemplateTimeout
+	}
+	_, err = w.Write(out.buf.Bytes())
+	return err
+}
+
+// limitedBuffer collects template output, failing writes past max bytes or
+// once stopped
+type limitedBuffer struct {
+	buf     bytes.Buffer
+	max     int
+	stopped atomic.Bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.stopped.Load() {
+		return 0, ErrTemplateTimeout
+	}
+	if b.max > 0 && b.buf.Len()+len(p) > b.max {
+		return 0, ErrOutputTooLarge
+	}
+	return b.buf.Write(p)
+}
+
+func newTemplateData(art *Art) *TemplateData {
+	data := &TemplateData{
+		Width:     art.Width,
+		Height:    art.Height,
+		Rows:      art.Rows,
+		Ramp:      art.Ramp,
+		Mode:      art.Options.Mode.String(),
+		Luminance: art.Options.Luminance.String(),
+		Threshold: art.Threshold,
+		Source:    art.Source,
+		Cells:     make([][]TemplateCell, len(art.Rows)),
+	}
+	if art.Background != BackgroundUnset {
+		data.Background = art.Background.String()
+	}
+	colors := art.cellColors()
+	lum := art.cellLuminance()
+	for y, row := range art.Rows {
+		chars := SplitCells(row)
+		cells := make([]TemplateCell, len(chars))
+		for x, ch := range chars {
+			cell := TemplateCell{X: x, Y: y, Char: ch}
+			if y < len(colors) && x < len(colors[y]) {
+				c := colors[y][x]
+				cell.R, cell.G, cell.B, cell.A = c.R, c.G, c.B, c.A
+				cell.Color = hexColor(c)
+			}
+			if y < len(lum) && x < len(lum[y]) {
+				cell.Luminance = lum[y][x]
+			}
+			cells[x] = cell
+		}
+		data.Cells[y] = cells
+	}
+	return data
+}
+
+// SplitCells splits text into the characters a reader sees, each drawn in
+// one cell: combining marks, variation selectors, skin tones and zero-width
+// joined sequences stay with the character they modify, and regional
+// indicators pair up into flags
+func SplitCells(text string) []string {
+	var cells []string
+	join := false
+	for _, r := range text {
+		switch {
+		case len(cells) > 0 && (join || r == '\u200d' || unicode.Is(unicode.M, r) ||
+			r >= 0x1f3fb && r <= 0x1f3ff || // skin tone modifiers
+			isRegionalIndicator(r) && isLoneRegionalIndicator(cells[len(cells)-1])):
+			cells[len(cells)-1] += string(r)
+			join = r == '\u200d'
+		default:
+			cells = append(cells, string(r))
+		}
+	}
+	return cells
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// isLoneRegionalIndicator reports whether cell is half of a flag
+func isLoneRegionalIndicator(cell string) bool {
+	r, size := utf8.DecodeRuneInString(cell)
+	return size == len(cell) && isRegionalIndicator(r)
+}
+
+var templateName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// LoadTemplateFormats parses every *.tmpl file in dir and registers it as an
+// output format. The format name is the file name up to the first dot and
+// the content type comes from any extension before .tmpl, so "carray.h.tmpl"
+// is the plain-text format "carray" and "report.html.tmpl" is served, and
+// escaped, as HTML.
+// It returns the registered names, sorted, and fails without registering
+// anything if a template does not parse or a name is taken.
+func LoadTemplateFormats(dir string, limits TemplateLimits) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	loaded := make(map[string]*Template)
+	for _, path := range paths {
+		base := strings.TrimSuffix(filepath.Base(path), ".tmpl")
+		name, ext, _ := strings.Cut(base, ".")
+		if !templateName.MatchString(name) {
+			return nil, fmt.Errorf("template %s: invalid format name %q", path, name)
+		}
+		if _, err := LookupFormat(name); err == nil || loaded[name] != nil {
+			return nil, fmt.Errorf("template %s: format %q already registered", path, name)
+		}
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		contentType := ""
+		if ext != "" {
+			contentType = mime.TypeByExtension(filepath.Ext("." + ext))
+		}
+		t, err := ParseTemplate(name, string(text), contentType, limits)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", path, err)
+		}
+		loaded[name] = t
+	}
+
+	names := make([]string, 0, len(loaded))
+	for name, t := range loaded {
+		RegisterFormat(name, t)
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
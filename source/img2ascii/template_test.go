@@ -0,0 +1,190 @@
+package img2ascii
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplateRender(t *testing.T) {
+	art := &Art{
+		Width:  2,
+		Height: 2,
+		Rows:   []string{"@.", "\U0001F7E5x"},
+		Colors: [][]color.RGBA{{red, blue}, {red, blue}},
+		Options: ConversionOptions{
+			Mode: ModeDefault,
+		},
+	}
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"rows", `{{range .Rows}}{{quote .}},{{end}}`, `"@.","🟥x",`},
+		{"cells", `{{range .Cells}}{{range .}}{{.X}}{{.Y}}{{.Char}}{{.Color}} {{end}}{{end}}`,
+			"00@#ff0000 10.#0000ff 01🟥#ff0000 11x#0000ff "},
+		{"funcs", `{{.Rows | join "|" | upper}} {{add .Width 1}} {{json .Mode}}`, `@.|🟥X 3 "default"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseTemplate(tt.name, tt.text, "", DefaultTemplateLimits)
+			if err != nil {
+				t.Fatalf("ParseTemplate() error = %v", err)
+			}
+			if ct := tmpl.ContentType(); ct != "text/plain; charset=utf-8" {
+				t.Errorf("ContentType() = %q", ct)
+			}
+			var buf bytes.Buffer
+			if err := tmpl.Render(&buf, art); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("Render() = %q, want %q", buf.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestTemplateCellsFromRaster(t *testing.T) {
+	img := &Image{Res: Resolution{Width: 1, Height: 1}, Data: createTestImage(1, 1, color.White).Pix}
+	tmpl, err := ParseTemplate("t", `{{range .Cells}}{{range .}}{{.Color}} {{.Luminance}}{{end}}{{end}}`, "", DefaultTemplateLimits)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Render(&buf, img.toArt(ConversionOptions{})); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if buf.String() != "#ffffff 255" {
+		t.Errorf("Render() = %q, want %q", buf.String(), "#ffffff 255")
+	}
+}
+
+func TestTemplateLimits(t *testing.T) {
+	art := &Art{Width: 2, Height: 1, Rows: []string{"@@"}}
+	tests := []struct {
+		name   string
+		text   string
+		limits TemplateLimits
+		err    error
+	}{
+		{"output", `{{range 100}}{{$.Rows}}{{end}}`, TemplateLimits{MaxOutput: 64}, ErrOutputTooLarge},
+		{"timeout", `{{range 1000000000}}.{{end}}`, TemplateLimits{Timeout: 10 * time.Millisecond}, ErrTemplateTimeout},
+		{"timeout in a function", `{{$n := 0}}{{range 1000000000}}{{$n = add $n 1}}{{end}}{{$n}}`, TemplateLimits{Timeout: 10 * time.Millisecond}, ErrTemplateTimeout},
+		{"cells", `{{.Rows}}`, TemplateLimits{MaxCells: 1}, ErrOutputTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseTemplate(tt.name, tt.text, "", tt.limits)
+			if err != nil {
+				t.Fatalf("ParseTemplate() error = %v", err)
+			}
+			var buf bytes.Buffer
+			if err := tmpl.Render(&buf, art); !errors.Is(err, tt.err) {
+				t.Errorf("Render() error = %v, want %v", err, tt.err)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("Render() wrote %d bytes after failing", buf.Len())
+			}
+		})
+	}
+}
+
+func TestSplitCells(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{"ab", []string{"a", "b"}},
+		{"e\u0301\u0323x", []string{"e\u0301\u0323", "x"}},
+		{"\u2764\ufe0f\U0001f44d\U0001f3fd", []string{"\u2764\ufe0f", "\U0001f44d\U0001f3fd"}},
+		{"\U0001f469\u200d\U0001f4bb.", []string{"\U0001f469\u200d\U0001f4bb", "."}},
+		{"\U0001f1eb\U0001f1f7\U0001f1e9\U0001f1ea\U0001f1ee", []string{"\U0001f1eb\U0001f1f7", "\U0001f1e9\U0001f1ea", "\U0001f1ee"}},
+	}
+	for _, tt := range tests {
+		if got := SplitCells(tt.text); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("SplitCells(%q) = %q, want %q", tt.text, got, tt.expected)
+		}
+	}
+}
+
+func TestTemplateEscapesMarkup(t *testing.T) {
+	art := &Art{Width: 8, Height: 1, Rows: []string{"<script>"}}
+	tests := []struct {
+		contentType string
+		expected    string
+	}{
+		{"text/html; charset=utf-8", "<pre>&lt;script&gt;</pre>"},
+		{"application/xhtml+xml", "<pre>&lt;script&gt;</pre>"},
+		{"text/xml; charset=utf-8", "<pre>&lt;script&gt;</pre>"},
+		{"text/plain; charset=utf-8", "<pre><script></pre>"},
+		{"", "<pre><script></pre>"},
+	}
+	for _, tt := range tests {
+		tmpl, err := ParseTemplate("t", `<pre>{{index .Rows 0}}</pre>`, tt.contentType, DefaultTemplateLimits)
+		if err != nil {
+			t.Fatalf("ParseTemplate() error = %v", err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Render(&buf, art); err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		if buf.String() != tt.expected {
+			t.Errorf("%q: Render() = %q, want %q", tt.contentType, buf.String(), tt.expected)
+		}
+	}
+}
+
+func TestLoadTemplateFormats(t *testing.T) {
+	names, err := LoadTemplateFormats("../../templates", DefaultTemplateLimits)
+	if err != nil {
+		t.Fatalf("LoadTemplateFormats() error = %v", err)
+	}
+	t.Cleanup(func() {
+		for _, name := range names {
+			unregisterFormat(name)
+		}
+	})
+	if strings.Join(names, ",") != "carray,cells,latex" {
+		t.Errorf("LoadTemplateFormats() = %v", names)
+	}
+	r, err := LookupFormat("cells")
+	if err != nil {
+		t.Fatalf("LookupFormat() error = %v", err)
+	}
+	if !strings.HasPrefix(r.ContentType(), "application/json") {
+		t.Errorf("cells ContentType() = %q", r.ContentType())
+	}
+	var buf bytes.Buffer
+	if err := r.Render(&buf, &Art{Width: 1, Height: 1, Rows: []string{"\""}}); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"char":"\""`) {
+		t.Errorf("Render() = %s", buf.String())
+	}
+
+	tests := []struct {
+		file string
+		text string
+	}{
+		{"text.tmpl", "taken"},
+		{"Bad_Name.tmpl", "name"},
+		{"broken.tmpl", "{{.Rows"},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.text), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadTemplateFormats(dir, DefaultTemplateLimits); err == nil {
+			t.Errorf("%s: expected error", tt.file)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		incoming string
+		reused   bool
+	}{
+		{"No incoming ID", "", false},
+		{"Valid incoming ID", "abc-123_DEF", true},
+		{"Invalid incoming ID", "bad id\nwith newline", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			r := gin.New()
+			r.Use(RequestID())
+			r.GET("/test", func(c *gin.Context) {
+				seen = GetRequestID(c)
+				c.String(200, "OK")
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/test", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			r.ServeHTTP(w, req)
+
+			if seen == "" {
+				t.Fatal("Expected a request ID to be assigned")
+			}
+			if got := w.Header().Get(RequestIDHeader); got != seen {
+				t.Errorf("Response header = %q, want %q", got, seen)
+			}
+			if (seen == tt.incoming) != tt.reused {
+				t.Errorf("Request ID %q, reuse of %q want %v", seen, tt.incoming, tt.reused)
+			}
+		})
+	}
+}
+
+func TestRequestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	r := gin.New()
+	r.Use(RequestID())
+	r.Use(RequestLogger(logger))
+	r.GET("/test", func(c *gin.Context) {
+		c.String(500, "boom")
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	r.ServeHTTP(w, req)
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Failed to parse log record %q: %v", buf.String(), err)
+	}
+
+	expected := map[string]any{
+		"level":      "ERROR",
+		"msg":        "request",
+		"request_id": "req-1",
+		"method":     "GET",
+		"path":       "/test",
+		"status":     float64(500),
+	}
+	for k, v := range expected {
+		if record[k] != v {
+			t.Errorf("Log field %q = %v, want %v", k, record[k], v)
+		}
+	}
+	if _, ok := record["duration"]; !ok {
+		t.Error("Log record is missing duration")
+	}
+}
@@ -5,15 +5,50 @@ document.addEventListener("DOMContentLoaded", function () {
     var aspectMode = document.getElementById("aspectMode");
     var sizeOptions = document.getElementById("sizeOptions");
 
+    var budgetOptions = document.getElementById("budgetOptions");
+    var sizedModes = ["fixed", "contain", "cover", "width", "height"];
+
     // Handle aspect mode changes
-    if (aspectMode && sizeOptions) {
+    if (aspectMode && sizeOptions && budgetOptions) {
         aspectMode.addEventListener("change", function() {
-            if (aspectMode.value === "fixed") {
+            if (sizedModes.indexOf(aspectMode.value) !== -1) {
                 sizeOptions.style.display = "block";
             } else {
                 sizeOptions.style.display = "none";
             }
+            budgetOptions.style.display = aspectMode.value === "budget" ? "block" : "none";
+        });
+    }
+
+    // Show the settings relevant to the selected style
+    var mode = document.getElementById("mode");
+    var thresholdOptions = document.getElementById("thresholdOptions");
+    var posterizeOptions = document.getElementById("posterizeOptions");
+    var textFillOptions = document.getElementById("textFillOptions");
+    var emojiOptions = document.getElementById("emojiOptions");
+    if (mode && thresholdOptions && posterizeOptions && textFillOptions && emojiOptions) {
+        mode.addEventListener("change", function() {
+            thresholdOptions.style.display = mode.value === "threshold" || mode.value === "textfill" ? "block" : "none";
+            posterizeOptions.style.display = mode.value === "posterize" ? "block" : "none";
+            textFillOptions.style.display = mode.value === "textfill" ? "block" : "none";
+            emojiOptions.style.display = mode.value === "emoji" ? "block" : "none";
+        });
+    }
+
+    // Render each character in its cell's colour
+    function renderColored(art) {
+        var fragment = document.createDocumentFragment();
+        art.rows.forEach(function (row, y) {
+            Array.from(row).forEach(function (ch, x) {
+                var span = document.createElement("span");
+                span.textContent = ch;
+                span.style.color = art.colors[y][x];
+                fragment.appendChild(span);
+            });
+            fragment.appendChild(document.createTextNode("\n"));
         });
+        asciiOutput.textContent = "";
+        asciiOutput.appendChild(fragment);
     }
 
     if (form && submit && asciiOutput) {
@@ -22,16 +57,21 @@ document.addEventListener("DOMContentLoaded", function () {
             submit.disabled = true;
             asciiOutput.textContent = "Uploading...";
             var formData = new FormData(form);
+            formData.append("format", "json");
             fetch("/upload", {
                 method: "POST",
                 body: formData
             })
             .then(response => {
                 if (!response.ok) throw new Error(response.statusText);
-                return response.text();
+                return response.json();
             })
-            .then(text => {
-                asciiOutput.textContent = text;
+            .then(art => {
+                if (art.options.fillAll && art.colors) {
+                    renderColored(art);
+                } else {
+                    asciiOutput.textContent = art.rows.join("\n");
+                }
             })
             .catch(error => {
                 asciiOutput.textContent = "Error: " + error.message;
@@ -69,4 +109,70 @@ document.addEventListener("DOMContentLoaded", function () {
             });
         });
     }
+
+    var bannerFont = document.getElementById("bannerFont");
+    var fontAxes = {};
+    // Enable the weight and width inputs for the axes the chosen font has,
+    // limited to their ranges; disabled inputs are not submitted
+    function updateAxes() {
+        var axes = fontAxes[bannerFont.value] || [];
+        [["bannerWeight", "wght"], ["bannerWidth", "wdth"]].forEach(function (pair) {
+            var input = document.getElementById(pair[0]);
+            var axis = axes.find(function (a) { return a.tag === pair[1]; });
+            input.disabled = !axis;
+            input.min = axis ? axis.min : "";
+            input.max = axis ? axis.max : "";
+            input.value = axis ? axis.default : "";
+        });
+    }
+    if (bannerFont) {
+        fetch("/fonts")
+            .then(response => response.json())
+            .then(data => {
+                data.fonts.forEach(function (f) {
+                    var option = document.createElement("option");
+                    option.value = f.name;
+                    option.textContent = f.style ? f.family + " " + f.style : f.name;
+                    option.selected = f.name === data.default;
+                    bannerFont.appendChild(option);
+                    fontAxes[f.name] = f.axes;
+                });
+                updateAxes();
+                bannerFont.addEventListener("change", updateAxes);
+            })
+            .catch(function () {
+                bannerFont.disabled = true;
+            });
+    }
+
+    var ansiForm = document.getElementById("ansiView");
+    var ansiSubmit = document.getElementById("ansiSubmit");
+    if (ansiForm && ansiSubmit && asciiOutput) {
+        ansiForm.addEventListener("submit", function (event) {
+            event.preventDefault();
+            ansiSubmit.disabled = true;
+            asciiOutput.textContent = "Rendering...";
+            fetch("/ansi", {
+                method: "POST",
+                body: new FormData(ansiForm)
+            })
+            .then(response => {
+                if (!response.ok) throw new Error(response.statusText);
+                return response.blob();
+            })
+            .then(blob => {
+                var img = document.createElement("img");
+                img.alt = "Rendered ANSI art";
+                img.src = URL.createObjectURL(blob);
+                asciiOutput.textContent = "";
+                asciiOutput.appendChild(img);
+            })
+            .catch(error => {
+                asciiOutput.textContent = "Error: " + error.message;
+            })
+            .finally(() => {
+                ansiSubmit.disabled = false;
+            });
+        });
+    }
 });
\ No newline at end of file